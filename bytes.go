@@ -0,0 +1,76 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// Converts 8 big-endian bytes, the network byte order, into a snowflake ID.
+func FromBytes(b []byte) (ID, error) {
+	if len(b) != 8 {
+		return Invalid, &ErrorInvalid
+	}
+
+	return fromUint64(binary.BigEndian.Uint64(b))
+}
+
+// Converts 8 little-endian bytes into a snowflake ID, e.g. to import
+// legacy exports that stored IDs byte-swapped, see `LooksByteSwapped`.
+func FromBytesLE(b []byte) (ID, error) {
+	if len(b) != 8 {
+		return Invalid, &ErrorInvalid
+	}
+
+	return fromUint64(binary.LittleEndian.Uint64(b))
+}
+
+// Returns the big-endian bytes of the ID with leading zeros trimmed, the
+// representation for protobuf `bytes` fields. The zero ID yields no bytes,
+// matching the proto default of an unset field.
+func (id ID) MarshalProto() []byte {
+	b := binary.BigEndian.AppendUint64(make([]byte, 0, 8), uint64(id))
+	return b[bits.LeadingZeros64(uint64(id))/8:]
+}
+
+// Converts a protobuf `bytes` field written by `MarshalProto` into a
+// snowflake ID. Up to 8 big-endian bytes are accepted, shorter inputs are
+// zero-extended and an empty field yields the zero ID.
+func UnmarshalProto(b []byte) (ID, error) {
+	if len(b) > 8 {
+		return Invalid, fmt.Errorf("%w: %d bytes exceed 8", &ErrorInvalid, len(b))
+	}
+
+	var full [8]byte
+	copy(full[8-len(b):], b)
+
+	return fromUint64(binary.BigEndian.Uint64(full[:]))
+}
+
+func fromUint64(v uint64) (ID, error) {
+	if v > 1<<63-1 {
+		return Invalid, &ErrorReservedBitSet
+	}
+
+	return ID(v), nil
+}
+
+// Reports whether a value is more plausible as a snowflake once its bytes
+// are swapped, i.e. the swapped value looks like a snowflake, see
+// `LooksLikeSnowflake`, and the raw value does not or is older. Swapped
+// recent IDs often still look like old ones, hence the recency check.
+// A heuristic for a one-time repair of legacy data, combine with
+// `FromBytesLE` or `Swapped`.
+func LooksByteSwapped(v int64) bool {
+	swapped := Swapped(v)
+	if !LooksLikeSnowflake(swapped) {
+		return false
+	}
+
+	return !LooksLikeSnowflake(v) || ID(swapped).Time() > ID(v).Time()
+}
+
+// Returns the value with its byte order reversed.
+func Swapped(v int64) int64 {
+	return int64(bits.ReverseBytes64(uint64(v)))
+}
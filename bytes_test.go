@@ -0,0 +1,120 @@
+package snowflake
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFromBytes(t *testing.T) {
+	tests := []struct {
+		input  []byte
+		verify ID
+		err    error
+	}{
+		{[]byte{0x04, 0x3b, 0xa9, 0x20, 0x0f, 0xc2, 0x30, 0x00}, ID(305023354946072576), nil},
+		{[]byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, ID(9223372036854775807), nil},
+		{[]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, Invalid, &ErrorReservedBitSet},
+		{[]byte{0x04, 0x3b}, Invalid, &ErrorInvalid},
+		{nil, Invalid, &ErrorInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_FromBytes_%x", test.input), func(t *testing.T) {
+			id, err := FromBytes(test.input)
+			if id != test.verify || !errors.Is(err, test.err) {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id), err, int64(test.verify), test.err)
+			}
+
+			// Same bytes in reverse order.
+			reversed := make([]byte, len(test.input))
+			for i, b := range test.input {
+				reversed[len(reversed)-1-i] = b
+			}
+
+			id, err = FromBytesLE(reversed)
+			if id != test.verify || !errors.Is(err, test.err) {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id), err, int64(test.verify), test.err)
+			}
+		})
+	}
+}
+
+func TestMarshalProto(t *testing.T) {
+	tests := []struct {
+		id     ID
+		verify []byte
+	}{
+		{ID(305023354946072576), []byte{0x04, 0x3b, 0xa9, 0x20, 0x0f, 0xc2, 0x30, 0x00}},
+		{ID(9223372036854775807), []byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{ID(0x1234), []byte{0x12, 0x34}},
+		{ID(1), []byte{0x01}},
+		{ID(0), []byte{}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_MarshalProto_%d", int64(test.id)), func(t *testing.T) {
+			b := test.id.MarshalProto()
+			if !bytes.Equal(b, test.verify) {
+				t.Errorf("got '%x', want '%x'", b, test.verify)
+			}
+
+			id, err := UnmarshalProto(b)
+			if id != test.id || err != nil {
+				t.Errorf("got '%v' (%v), want '%v'", int64(id), err, int64(test.id))
+			}
+		})
+	}
+}
+
+func TestUnmarshalProto(t *testing.T) {
+	tests := []struct {
+		input  []byte
+		verify ID
+		err    error
+	}{
+		{[]byte{0x00, 0x00, 0x12, 0x34}, ID(0x1234), nil},
+		{nil, ID(0), nil},
+		{[]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, Invalid, &ErrorReservedBitSet},
+		{make([]byte, 9), Invalid, &ErrorInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_UnmarshalProto_%x", test.input), func(t *testing.T) {
+			id, err := UnmarshalProto(test.input)
+			if id != test.verify || !errors.Is(err, test.err) {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id), err, int64(test.verify), test.err)
+			}
+		})
+	}
+}
+
+func TestLooksByteSwapped(t *testing.T) {
+	// Swapped, its sequence becomes the high byte, i.e. a 2021 timestamp.
+	id := IDFromEpochMillis(time.Now().UnixMilli()-Epoch, 5<<6|35, 1)
+
+	// Legacy export stored the ID little-endian, read as big-endian.
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(id))
+	legacy := int64(binary.BigEndian.Uint64(b[:]))
+
+	if LooksByteSwapped(int64(id)) {
+		t.Errorf("got 'true', want 'false' for '%d'", int64(id))
+	}
+
+	if !LooksByteSwapped(legacy) {
+		t.Fatalf("got 'false', want 'true' for '%d'", legacy)
+	}
+
+	if repaired, err := FromBytesLE(b[:]); err != nil || repaired != id || ID(Swapped(legacy)) != id {
+		t.Errorf("got '%v' (%v), want '%v'", int64(repaired), err, int64(id))
+	}
+
+	// Neither plausible.
+	if LooksByteSwapped(123123) {
+		t.Errorf("got 'true', want 'false' for '123123'")
+	}
+}
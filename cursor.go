@@ -0,0 +1,38 @@
+package snowflake
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+)
+
+// Returns an opaque, URL safe keyset pagination cursor for the snowflake,
+// i.e. the base64 url encoded big-endian int64. Deliberately distinct
+// from the human facing base 54 representation, so the two can not be
+// confused.
+func (id ID) NextCursor() string {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(id))
+
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+// Converts a cursor from `NextCursor` into a snowflake ID.
+func ParseCursor(s string) (ID, error) {
+	var b [8]byte
+
+	// 8 bytes encode to exactly 11 chars.
+	if len(s) != 11 {
+		return Invalid, &ErrorInvalidCursor
+	}
+
+	if _, err := base64.RawURLEncoding.Decode(b[:], []byte(s)); err != nil {
+		return Invalid, &ErrorInvalidCursor
+	}
+
+	id := ID(binary.BigEndian.Uint64(b[:]))
+	if id < 0 {
+		return Invalid, &ErrorInvalidCursor
+	}
+
+	return id, nil
+}
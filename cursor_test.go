@@ -0,0 +1,43 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	tests := []struct {
+		id     ID
+		verify string
+	}{
+		{ID(0), "AAAAAAAAAAA"},
+		{ID(305023354946072576), "BDupIA_CMAA"},
+		{ID(9223372036854775807), "f_________8"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Cursor_%d", int64(test.id)), func(t *testing.T) {
+			cursor := test.id.NextCursor()
+			id, err := ParseCursor(cursor)
+
+			if cursor != test.verify {
+				t.Errorf("got '%s', want '%s'", cursor, test.verify)
+			} else if err != nil || id != test.id {
+				t.Errorf("got '%v' (%v), want '%v'", int64(id), err, int64(test.id))
+			}
+		})
+	}
+}
+
+func TestParseCursorInvalid(t *testing.T) {
+	tests := []string{"", "8uyZY2sj3re=", "BDupIA_CMA", "BDupIA_CMAAA", "BDupIA+CMAA", "__________8"}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseCursor_%s", test), func(t *testing.T) {
+			if id, err := ParseCursor(test); !errors.Is(err, &ErrorInvalidCursor) || id != Invalid {
+				t.Errorf("got '%v' (%v), want '%v'", int64(id), err, &ErrorInvalidCursor)
+			}
+		})
+	}
+}
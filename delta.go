@@ -0,0 +1,47 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"slices"
+)
+
+// Returns a compact representation of a set of IDs: the smallest ID as
+// uvarint, followed by the uvarint deltas between consecutive IDs. Since
+// sorted snowflakes are close, a delta within a millisecond takes a single
+// byte, and one across a few milliseconds 4 bytes, instead of 8.
+// Unsorted input is sorted, the input slice is left untouched.
+func EncodeDeltas(ids []ID) []byte {
+	if !slices.IsSorted(ids) {
+		ids = slices.Clone(ids)
+		slices.Sort(ids)
+	}
+
+	b := make([]byte, 0, len(ids)*4)
+	var previous uint64
+
+	for _, id := range ids {
+		b = binary.AppendUvarint(b, uint64(id)-previous)
+		previous = uint64(id)
+	}
+
+	return b
+}
+
+// Converts the output of `EncodeDeltas` back into sorted IDs.
+func DecodeDeltas(b []byte) ([]ID, error) {
+	var ids []ID
+	var previous uint64
+
+	for len(b) > 0 {
+		delta, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, &ErrorInvalid
+		}
+
+		previous += delta
+		ids = append(ids, ID(previous))
+		b = b[n:]
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,76 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDeltas(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	g, _ := NewGenerator("arn", 35, WithClock(clock.Now))
+
+	// Bursts of IDs over 1000 ms.
+	var generated []ID
+	for i := 0; i < 1000; i++ {
+		for j := 0; j < 5; j++ {
+			id, _ := g.Generate()
+			generated = append(generated, id)
+		}
+
+		clock.Add(time.Millisecond)
+	}
+
+	shuffled := slices.Clone(generated)
+	slices.Reverse(shuffled)
+
+	tests := []struct {
+		name string
+		ids  []ID
+	}{
+		{"Sorted", generated},
+		{"Unsorted", shuffled},
+		{"Single", []ID{ID(305023354946072576)}},
+		{"Duplicates", []ID{1, 1, 2}},
+		{"Empty", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Deltas_%s", test.name), func(t *testing.T) {
+			input := slices.Clone(test.ids)
+			encoded := EncodeDeltas(test.ids)
+			decoded, err := DecodeDeltas(encoded)
+
+			want := slices.Clone(test.ids)
+			slices.Sort(want)
+			if err != nil || !slices.Equal(decoded, want) {
+				t.Errorf("got '%v' (%v), want '%v'", decoded, err, want)
+			} else if !slices.Equal(test.ids, input) {
+				t.Errorf("input was modified")
+			}
+		})
+	}
+
+	// 1 byte within, 4 bytes across milliseconds, compared to 8 bytes each.
+	if got, naive := len(EncodeDeltas(generated)), 8*len(generated); got > naive/2 {
+		t.Errorf("got '%d' bytes, want at most half of '%d'", got, naive)
+	}
+}
+
+func TestDecodeDeltasInvalid(t *testing.T) {
+	tests := [][]byte{
+		{0x80},
+		{0x01, 0xFF, 0xFF},
+		{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_DecodeDeltas_%x", test), func(t *testing.T) {
+			if _, err := DecodeDeltas(test); !errors.Is(err, &ErrorInvalid) {
+				t.Errorf("got '%v', want '%v'", err, &ErrorInvalid)
+			}
+		})
+	}
+}
@@ -1,37 +1,210 @@
 package snowflake
 
 import (
+	"fmt"
 	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Scrambled version of "0123456789abcdefghjkmnprstuvwxyzACDEFGHJKLMNPQRTUVWXYZ".
-const alphabet string = "g82FcYyTeUr0vsn1Jb9NmLMPuHGhVztRp4f3jDk5Zd6ECaw7AWQKXx"
+const DefaultAlphabet string = "g82FcYyTeUr0vsn1Jb9NmLMPuHGhVztRp4f3jDk5Zd6ECaw7AWQKXx"
 
 // Supports up to base 84. Tests will fail if map is changed (intentionally).
 const debugAlphabet string = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-._~:?#[]@!$&'()*+,;%="
 
-// Lookup alphabet char to its position in the alphabet.
-var decodeMap [256]byte
+// Encoding configuration, immutable once in use, such that readers see
+// alphabet and lookup of the same setter call without locking.
+type codec struct {
+	alphabet string
+	mode     EncodingMode
 
-// Pre-populates `decodeMap` to speed up parsing.
+	// Lookup alphabet char to its position in the alphabet.
+	decodeMap [256]byte
+
+	// Whether `decodeMap` folds letter cases, see `SetCaseInsensitive`.
+	caseInsensitive bool
+}
+
+// Encoding configuration in use, replaced as a whole by the setters.
+var active atomic.Pointer[codec]
+
+// Serializes the setters, which derive the next codec from the active one.
+var alphabetMutex sync.Mutex
+
+// Tradeoff between hiding the time of IDs and string sortability.
+type EncodingMode int
+
+const (
+	// Default, encodes with a scrambled alphabet to hide creation times.
+	Scrambled EncodingMode = iota
+	// Encodes with an ASCII ordered alphabet, zero padded to 11 chars,
+	// such that strings sort like IDs. Leaks creation times.
+	Sortable
+)
+
+// ASCII ordered version of `DefaultAlphabet`.
+const sortableAlphabet string = "0123456789ACDEFGHJKLMNPQRTUVWXYZabcdefghjkmnprstuvwxyz"
+
+// Verifies that an encoding alphabet consists of exactly 54 unique bytes.
+// A duplicate character would silently produce a lossy decode map.
+func validateAlphabet(a string) error {
+	if len(a) != 54 {
+		return &ErrorAlphabetLength
+	}
+
+	var seen [256]bool
+	for i := 0; i < len(a); i++ {
+		if seen[a[i]] {
+			return &ErrorAlphabetDuplicate
+		}
+
+		seen[a[i]] = true
+	}
+
+	return nil
+}
+
+// Verifies that no letter of an alphabet appears in both cases,
+// a prerequisite for case insensitive decoding.
+func validateAlphabetCase(a string) error {
+	for i := 0; i < len(a); i++ {
+		if other := swapCase(a[i]); other != a[i] && strings.IndexByte(a, other) >= 0 {
+			return fmt.Errorf("%w: %q and %q", &ErrorAlphabetCase, a[i], other)
+		}
+	}
+
+	return nil
+}
+
+// Returns the other case of an ASCII letter, any other byte as is.
+func swapCase(c byte) byte {
+	if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
+		return c ^ 0x20
+	}
+
+	return c
+}
+
+// Creates a codec with a pre-populated `decodeMap` to speed up parsing.
 // ~20x speedup using [256]byte lookup compared to map[byte]byte.
-func initDecodeMap() {
+func newCodec(a string, mode EncodingMode, caseInsensitive bool) *codec {
+	c := &codec{alphabet: a, mode: mode, caseInsensitive: caseInsensitive}
+
 	// Invalid characters are marked with 0xFF.
-	for i := 0; i < len(decodeMap); i++ {
-		decodeMap[i] = 0xFF
+	for i := 0; i < len(c.decodeMap); i++ {
+		c.decodeMap[i] = 0xFF
+	}
+
+	for i := 0; i < len(a); i++ {
+		c.decodeMap[a[i]] = byte(i)
+
+		// Folding happens in the lookup, parsing costs the same.
+		if caseInsensitive {
+			c.decodeMap[swapCase(a[i])] = byte(i)
+		}
+	}
+
+	return c
+}
+
+// Rebuilds the decode lookup from the current alphabet, e.g. to reset
+// it deterministically in tests.
+func RebuildDecodeMap() {
+	alphabetMutex.Lock()
+	defer alphabetMutex.Unlock()
+
+	c := active.Load()
+	active.Store(newCodec(c.alphabet, c.mode, c.caseInsensitive))
+}
+
+// Replaces the encoding alphabet, which must consist of 54 unique bytes,
+// and rebuilds the decode lookup. Previously encoded IDs no longer decode.
+// Returns `ErrorAlphabetCase` if case insensitive decoding is enabled
+// and the alphabet contains both cases of a letter. Safe to call while
+// IDs are encoded or parsed, each call sees either alphabet as a whole.
+func SetAlphabet(a string) error {
+	if err := validateAlphabet(a); err != nil {
+		return err
 	}
 
-	for i := 0; i < len(alphabet); i++ {
-		decodeMap[alphabet[i]] = byte(i)
+	alphabetMutex.Lock()
+	defer alphabetMutex.Unlock()
+
+	c := active.Load()
+	if c.caseInsensitive {
+		if err := validateAlphabetCase(a); err != nil {
+			return err
+		}
 	}
+
+	active.Store(newCodec(a, c.mode, c.caseInsensitive))
+
+	return nil
 }
 
+// Selects what `String` produces and `Parse` accepts, replacing any
+// custom alphabet of `SetAlphabet` and disabling `SetCaseInsensitive`,
+// since both mode alphabets use mixed case. IDs encoded in one mode do not
+// decode to the same ID in the other. Both modes parse unpadded input.
+// Safe to call while IDs are encoded or parsed, like `SetAlphabet`.
+func SetEncodingMode(mode EncodingMode) error {
+	a := DefaultAlphabet
+	switch mode {
+	case Scrambled:
+	case Sortable:
+		a = sortableAlphabet
+	default:
+		return fmt.Errorf("%w: unknown encoding mode %d", &ErrorInvalidConfig, mode)
+	}
+
+	alphabetMutex.Lock()
+	defer alphabetMutex.Unlock()
+
+	active.Store(newCodec(a, mode, false))
+
+	return nil
+}
+
+// Makes `Parse` accept either case of the alphabet letters, e.g. for
+// transports that lowercase IDs. Encoding is unaffected. Requires a custom
+// alphabet without both cases of any letter, see `SetAlphabet`, otherwise
+// returns `ErrorAlphabetCase`, which the default alphabet does.
+// Safe to call while IDs are encoded or parsed, like `SetAlphabet`.
+func SetCaseInsensitive(enabled bool) error {
+	alphabetMutex.Lock()
+	defer alphabetMutex.Unlock()
+
+	c := active.Load()
+	if enabled {
+		if err := validateAlphabetCase(c.alphabet); err != nil {
+			return err
+		}
+	}
+
+	active.Store(newCodec(c.alphabet, c.mode, enabled))
+
+	return nil
+}
+
+// Smallest snowflake that takes the full 11 chars, 54^10.
+// Every ID generated after 2023-03-09 13:46:20.344 UTC is at least this large.
+const minFullWidth ID = 210832519264920576
+
 // Returns the base 54 encoded representation of a snowflake.
 func (id ID) base54() (string, error) {
-	if id < 0 {
+	return active.Load().encode(id)
+}
+
+// Encodes a snowflake with the alphabet and mode of the codec.
+func (c *codec) encode(id ID) (string, error) {
+	if id >= minFullWidth {
+		return c.encodeFullWidth(id), nil
+	} else if id < 0 {
 		return "", &ErrorInvalid
-	} else if id < 54 {
-		return string(alphabet[id]), nil
+	} else if id < 54 && c.mode != Sortable {
+		return string(c.alphabet[id]), nil
 	}
 
 	// 11 is ceil(log(54, MAX_INT64))
@@ -39,19 +212,101 @@ func (id ID) base54() (string, error) {
 	i := 10
 
 	for id >= 54 {
-		b[i] = alphabet[id%54]
+		b[i] = c.alphabet[id%54]
 		id /= 54
 		i--
 	}
 
-	b[i] = alphabet[id]
+	b[i] = c.alphabet[id]
+
+	// Zero padding keeps sortable strings in numeric order.
+	if c.mode == Sortable {
+		for i > 0 {
+			i--
+			b[i] = c.alphabet[0]
+		}
+	}
 
 	return string(b[i:]), nil
 }
 
+// Returns the number of base 54 characters needed to encode a
+// snowflake, between 1 and 11, or 0 for invalid IDs. Always 11 in
+// `Sortable` mode, which pads every ID to full width.
+func (id ID) EncodedLen() int {
+	if id < 0 {
+		return 0
+	} else if id >= minFullWidth || active.Load().mode == Sortable {
+		return 11
+	}
+
+	n := 1
+	for ; id >= 54; id /= 54 {
+		n++
+	}
+
+	return n
+}
+
+// Unrolled encoding for the common case of an 11 char snowflake.
+// Unsigned division by a constant avoids sign fixups, ~10% faster.
+func (c *codec) encodeFullWidth(id ID) string {
+	var b [11]byte
+	v := uint64(id)
+	alphabet := c.alphabet
+
+	b[10] = alphabet[v%54]
+	v /= 54
+	b[9] = alphabet[v%54]
+	v /= 54
+	b[8] = alphabet[v%54]
+	v /= 54
+	b[7] = alphabet[v%54]
+	v /= 54
+	b[6] = alphabet[v%54]
+	v /= 54
+	b[5] = alphabet[v%54]
+	v /= 54
+	b[4] = alphabet[v%54]
+	v /= 54
+	b[3] = alphabet[v%54]
+	v /= 54
+	b[2] = alphabet[v%54]
+	v /= 54
+	b[1] = alphabet[v%54]
+	v /= 54
+	b[0] = alphabet[v]
+
+	return string(b[:])
+}
+
 // Converts a base 54 encoded string into a snowflake ID.
 func decode54(b []byte) (ID, error) {
+	return active.Load().decode(b)
+}
+
+// Decodes a base 54 encoded string with the lookup of the codec.
+func (c *codec) decode(b []byte) (ID, error) {
+	// Longer inputs may wrap around to a positive value,
+	// which the overflow check below would not detect.
+	if len(b) > 11 {
+		return Invalid, &ErrorInvalid
+	}
+
+	// Would otherwise decode to a valid looking zero ID.
+	if len(b) == 0 {
+		return Invalid, &ErrorEmptyInput
+	} else if len(b) == 11 {
+		return c.decodeFullWidth(b)
+	}
+
+	return c.decodeLoop(b)
+}
+
+// General decoding for inputs of up to 11 chars.
+func (c *codec) decodeLoop(b []byte) (ID, error) {
 	var id int64
+	decodeMap := &c.decodeMap
 
 	for i := range b {
 		if decodeMap[b[i]] == 0xFF {
@@ -71,6 +326,284 @@ func decode54(b []byte) (ID, error) {
 	return ID(id), nil
 }
 
+// Unrolled decoding for the common case of an 11 char snowflake.
+// 54^11 < 2^64, hence the unsigned value can not wrap around.
+func (c *codec) decodeFullWidth(b []byte) (ID, error) {
+	_ = b[10] // Single bounds check.
+	decodeMap := &c.decodeMap
+
+	d0, d1, d2, d3 := decodeMap[b[0]], decodeMap[b[1]], decodeMap[b[2]], decodeMap[b[3]]
+	d4, d5, d6, d7 := decodeMap[b[4]], decodeMap[b[5]], decodeMap[b[6]], decodeMap[b[7]]
+	d8, d9, d10 := decodeMap[b[8]], decodeMap[b[9]], decodeMap[b[10]]
+
+	// Valid digits are below 54, only the 0xFF marker sets the high bit.
+	if (d0|d1|d2|d3|d4|d5|d6|d7|d8|d9|d10)&0x80 != 0 {
+		return Invalid, &ErrorInvalidByte
+	}
+
+	v := uint64(d0)
+	v = v*54 + uint64(d1)
+	v = v*54 + uint64(d2)
+	v = v*54 + uint64(d3)
+	v = v*54 + uint64(d4)
+	v = v*54 + uint64(d5)
+	v = v*54 + uint64(d6)
+	v = v*54 + uint64(d7)
+	v = v*54 + uint64(d8)
+	v = v*54 + uint64(d9)
+	v = v*54 + uint64(d10)
+
+	// Beyond int64, i.e. the unused high bit is set.
+	if v > math.MaxInt64 {
+		return Invalid, &ErrorInvalid
+	}
+
+	return ID(v), nil
+}
+
+// Computes a Luhn mod N check character over base 54 encoded input,
+// which detects any single character typo and most transpositions.
+// Expects all bytes to be valid alphabet characters.
+func (c *codec) checkChar(b []byte) byte {
+	factor, sum := 2, 0
+
+	for i := len(b) - 1; i >= 0; i-- {
+		addend := factor * int(c.decodeMap[b[i]])
+		sum += addend/54 + addend%54
+		factor = 3 - factor
+	}
+
+	return c.alphabet[(54-sum%54)%54]
+}
+
+// Returns the base 54 representation of a snowflake followed by a check
+// character, to catch typos when IDs are transcribed by humans.
+func (id ID) StringWithCheck() string {
+	c := active.Load()
+	encoded, err := c.encode(id)
+	if err != nil {
+		return ""
+	}
+
+	return encoded + string(c.checkChar([]byte(encoded)))
+}
+
+// Converts a base 54 encoded string with a trailing check
+// character, see `StringWithCheck`, into a snowflake ID. Compares
+// digit values, hence accepts case folded input, see `SetCaseInsensitive`.
+func ParseChecked(input string) (ID, error) {
+	if len(input) < 2 {
+		return Invalid, &ErrorInvalid
+	}
+
+	c := active.Load()
+	b := []byte(input)
+	id, err := c.decode(b[:len(b)-1])
+	if err != nil {
+		return Invalid, err
+	} else if c.decodeMap[b[len(b)-1]] == 0xFF {
+		return Invalid, &ErrorInvalidByte
+	} else if c.decodeMap[c.checkChar(b[:len(b)-1])] != c.decodeMap[b[len(b)-1]] {
+		return Invalid, &ErrorChecksumMismatch
+	}
+
+	return id, nil
+}
+
+// Returns the base 54 representation of a snowflake with a type prefix,
+// like Stripe's `cus_`, e.g. `ord_8uyZY2sj3re`. Returns an empty string
+// for invalid IDs.
+func (id ID) Prefixed(prefix string) string {
+	encoded := id.String()
+	if encoded == "" {
+		return ""
+	}
+
+	return prefix + "_" + encoded
+}
+
+// Version markers 0 to 7, none of which are part of the alphabet.
+const versionChars string = "BIOSiloq"
+
+// Returns the base 54 representation of a snowflake prefixed with a
+// version marker char for layouts 0 to 7, e.g. `B8uyZY2sj3re` for 0, so
+// parsers know which layout to use. Returns an empty string for invalid
+// IDs or versions.
+func (id ID) VersionedString(version byte) string {
+	encoded := id.String()
+	if encoded == "" || int(version) >= len(versionChars) {
+		return ""
+	}
+
+	return string(versionChars[version]) + encoded
+}
+
+// Splits a string from `VersionedString` into version and snowflake ID.
+// Returns `ErrorUnknownVersion` if it does not start with a version marker.
+func ParseVersioned(s string) (version byte, id ID, err error) {
+	if len(s) == 0 {
+		return 0, Invalid, &ErrorEmptyInput
+	}
+
+	i := strings.IndexByte(versionChars, s[0])
+	if i < 0 {
+		return 0, Invalid, &ErrorUnknownVersion
+	}
+
+	id, err = Parse(s[1:])
+	if err != nil {
+		return 0, Invalid, err
+	}
+
+	return byte(i), id, nil
+}
+
+// Returns a filesystem safe file name for a snowflake with the given
+// extension, e.g. `8uyZY2sj3re.json`. The extension is given without a
+// leading dot and may only contain ASCII letters, digits and inner dots.
+// Returns an empty string for invalid IDs or extensions.
+func (id ID) FileName(ext string) string {
+	encoded := id.String()
+	if encoded == "" || !validExtension(ext) {
+		return ""
+	} else if ext == "" {
+		return encoded
+	}
+
+	return encoded + "." + ext
+}
+
+// Reports whether an extension is safe for `FileName`, e.g. `tar.gz`.
+func validExtension(ext string) bool {
+	for i := 0; i < len(ext); i++ {
+		c := ext[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.' && i > 0 && i < len(ext)-1 && ext[i-1] != '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// Splits a prefixed snowflake from `Prefixed` on the last underscore,
+// returning the non-empty prefix and the decoded snowflake ID.
+func ParsePrefixed(s string) (prefix string, id ID, err error) {
+	i := strings.LastIndexByte(s, '_')
+	if i < 1 {
+		return "", Invalid, &ErrorInvalidPrefix
+	} else if i == len(s)-1 {
+		return "", Invalid, &ErrorInvalid
+	}
+
+	id, err = Parse(s[i+1:])
+	if err != nil {
+		return "", Invalid, err
+	}
+
+	return s[:i], id, nil
+}
+
+// Returns a stable 4 char tag derived from the machine id and sequence,
+// independent of the timestamp, e.g. for colored UI badges. Purely
+// presentational. The 21 bits are mixed bijectively, hence IDs that
+// differ in machine id or sequence never share a tag.
+func (id ID) ShortTag() string {
+	h := mix21(uint64(id))
+	alphabet := active.Load().alphabet
+
+	var b [4]byte
+	for i := 3; i >= 0; i-- {
+		b[i] = alphabet[h%54]
+		h /= 54
+	}
+
+	return string(b[:])
+}
+
+// Returns the base 54 encoded representation of an arbitrary byte slice,
+// e.g. for short human readable tokens. Like base58, every leading zero
+// byte is encoded as a leading zero char, so that it survives decoding.
+// Quadratic in the input length, intended for short inputs.
+func EncodeBytes(b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+
+	// log(256) / log(54) ~ 1.39016, rounded up to stay an upper bound.
+	size := ((len(b)-zeros)*1391+999)/1000 + 1
+	digits := make([]byte, size)
+	length := 0
+
+	for _, c := range b[zeros:] {
+		carry := int(c)
+		i := 0
+
+		for j := size - 1; (carry != 0 || i < length) && j >= 0; j-- {
+			carry += 256 * int(digits[j])
+			digits[j] = byte(carry % 54)
+			carry /= 54
+			i++
+		}
+
+		length = i
+	}
+
+	alphabet := active.Load().alphabet
+
+	out := make([]byte, zeros+length)
+	for i := 0; i < zeros; i++ {
+		out[i] = alphabet[0]
+	}
+
+	for i, d := range digits[size-length:] {
+		out[zeros+i] = alphabet[d]
+	}
+
+	return string(out)
+}
+
+// Converts a base 54 encoded string from `EncodeBytes` into a byte slice.
+func DecodeBytes(s string) ([]byte, error) {
+	decodeMap := &active.Load().decodeMap
+
+	zeros := 0
+	for zeros < len(s) && decodeMap[s[zeros]] == 0 {
+		zeros++
+	}
+
+	// log(54) / log(256) ~ 0.72
+	size := (len(s)-zeros)*72/100 + 1
+	b := make([]byte, size)
+	length := 0
+
+	for i := zeros; i < len(s); i++ {
+		if decodeMap[s[i]] == 0xFF {
+			return nil, &ErrorInvalidByte
+		}
+
+		carry := int(decodeMap[s[i]])
+		k := 0
+
+		for j := size - 1; (carry != 0 || k < length) && j >= 0; j-- {
+			carry += 54 * int(b[j])
+			b[j] = byte(carry % 256)
+			carry /= 256
+			k++
+		}
+
+		length = k
+	}
+
+	out := make([]byte, zeros+length)
+	copy(out[zeros:], b[size-length:])
+
+	return out, nil
+}
+
 // Private encode method for testing and verifying different
 // bases and encoding alphabets. Not optimised.
 func (id ID) baseEncode(base int64, encodeMap string) (string, error) {
@@ -86,7 +619,13 @@ func (id ID) baseEncode(base int64, encodeMap string) (string, error) {
 		return string(encodeMap[id]), nil
 	}
 
-	length := int(math.Ceil(logBase(float64(base), float64(id))))
+	// Count digits with integers, since ceil(log(base, x)) is off by
+	// one for exact powers of the base.
+	length := 1
+	for v := val; v >= base; v /= base {
+		length++
+	}
+
 	b := make([]byte, length)
 	i := length - 1
 
@@ -99,8 +638,3 @@ func (id ID) baseEncode(base int64, encodeMap string) (string, error) {
 	b[i] = encodeMap[val%base]
 	return string(b), nil
 }
-
-// log(base, x) = log(x) / log(base)
-func logBase(base float64, x float64) float64 {
-	return math.Log(x) / math.Log(base)
-}
@@ -1,8 +1,13 @@
 package snowflake
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEncode(t *testing.T) {
@@ -13,13 +18,16 @@ func TestEncode(t *testing.T) {
 		{ID(123), "21"},
 		{ID(123123), "6vF"},
 		{ID(123123123), "nHW1a"},
+		{minFullWidth - 1, "xxxxxxxxxx"},
+		{minFullWidth, "8gggggggggg"},
+		{ID(305023354946072576), "8uyZY2sj3re"},
 		{ID(1820096636282474496), "efUzLtM5yvu"},
 		{ID(9223372036854775807), "EZNmktHEz5H"},
 	}
 
 	for _, test := range tests {
 		t.Run(fmt.Sprintf("Test_%d", int64(test.id)), func(t *testing.T) {
-			slow, err1 := test.id.baseEncode(54, alphabet)
+			slow, err1 := test.id.baseEncode(54, active.Load().alphabet)
 			fast, err2 := test.id.base54()
 
 			if err1 != nil || err2 != nil {
@@ -57,7 +65,9 @@ func TestDecode(t *testing.T) {
 		{ID(123123123), "nHW1a"},
 		{ID(1820096636282474496), "efUzLtM5yvu"},
 		{ID(9223372036854775807), "EZNmktHEz5H"},
-		{Invalid, "xZNmktHEz5H"}, // overflow
+		{Invalid, "xZNmktHEz5H"},         // overflow
+		{Invalid, "8888888888888888888"}, // overflow, wraps around to positive
+		{Invalid, ""},                    // empty, not zero
 	}
 
 	for _, test := range tests {
@@ -73,9 +83,691 @@ func TestDecode(t *testing.T) {
 	}
 }
 
+// Realistic snowflake as generated in 2024.
+func BenchmarkBase54Realistic(b *testing.B) {
+	id := ID(305023354946072576)
+	for i := 0; i < b.N; i++ {
+		_, _ = id.base54()
+	}
+}
+
+func TestParseTrusted(t *testing.T) {
+	tests := []string{"21", "6vF", "8uyZY2sj3re", "EZNmktHEz5H"}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseTrusted_%s", test), func(t *testing.T) {
+			want, _ := Parse(test)
+
+			if id := ParseTrusted(test); id != want {
+				t.Errorf("got '%v', want '%v'", int64(id), int64(want))
+			}
+		})
+	}
+}
+
+// 7.8 ns/op, unrolled 11 char path, compare with BenchmarkDecode54Loop.
+func BenchmarkDecode54FullWidth(b *testing.B) {
+	c, input := active.Load(), []byte("8FaPRNs8Uks")
+	for i := 0; i < b.N; i++ {
+		_, _ = c.decodeFullWidth(input)
+	}
+}
+
+// 10.8 ns/op
+func BenchmarkDecode54Loop(b *testing.B) {
+	c, input := active.Load(), []byte("8FaPRNs8Uks")
+	for i := 0; i < b.N; i++ {
+		_, _ = c.decodeLoop(input)
+	}
+}
+
+// 7.1 ns/op, on par with `BenchmarkDecode54FullWidth` (6.9 ns/op on the
+// same machine) since folding happens in the lookup.
+func BenchmarkDecode54CaseInsensitive(b *testing.B) {
+	b.Cleanup(func() { _ = SetEncodingMode(Scrambled) })
+
+	_ = SetAlphabet("abcdefghijklmnopqrstuvwxyz0123456789-_.~!$&'()*+,;=:@%")
+	_ = SetCaseInsensitive(true)
+
+	input := []byte(strings.ToUpper(ID(305023354946072576).String()))
+	for i := 0; i < b.N; i++ {
+		_, _ = decode54(input)
+	}
+}
+
+func TestDecode54FullWidth(t *testing.T) {
+	tests := []string{"8FaPRNs8Uks", "8uyZY2sj3re", "EZNmktHEz5H", "EZNmktHEz5G", "xxxxxxxxxxx", "gggggggggg8", "8uyZY2sj3r!", "!uyZY2sj3re"}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Decode54FullWidth_%s", test), func(t *testing.T) {
+			c := active.Load()
+			id1, err1 := c.decodeFullWidth([]byte(test))
+			id2, err2 := c.decodeLoop([]byte(test))
+
+			if id1 != id2 || !errors.Is(err1, err2) {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id1), err1, int64(id2), err2)
+			}
+		})
+	}
+}
+
+// Compare with BenchmarkBaseDecode.
+func BenchmarkParseTrusted(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = ParseTrusted("8FaPRNs8Uks")
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	tests := []struct {
+		input  string
+		verify ID
+		err    error
+	}{
+		{"8uyZY2sj3re", ID(305023354946072576), nil},
+		{"EZNmktHEz5H", ID(9223372036854775807), nil},
+		{"EZNmktHEz5G", Invalid, &ErrorReservedBitSet}, // MaxInt64 + 1
+		{"xZNmktHEz5H", Invalid, &ErrorReservedBitSet},
+		{"8888888888888888888", Invalid, &ErrorInvalid},
+		{"8uy!", Invalid, &ErrorInvalidByte},
+		{"", Invalid, &ErrorEmptyInput},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseStrict_%s", test.input), func(t *testing.T) {
+			id, err := ParseStrict(test.input)
+
+			if id != test.verify || !errors.Is(err, test.err) {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id), err, int64(test.verify), test.err)
+			}
+		})
+	}
+}
+
+func TestParseMinLen(t *testing.T) {
+	tests := []struct {
+		input  string
+		min    int
+		verify ID
+		err    error
+	}{
+		{"8uyZY2sj3re", 11, ID(305023354946072576), nil},
+		{"8uyZY2sj3r", 11, Invalid, &ErrorInvalid},
+		{"n", 11, Invalid, &ErrorInvalid},
+		{"n", 1, ID(14), nil},
+		{"", 0, Invalid, &ErrorEmptyInput},
+		{"8uy!ZY2sj3r", 11, Invalid, &ErrorInvalidByte},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseMinLen_%s_%d", test.input, test.min), func(t *testing.T) {
+			id, err := ParseMinLen(test.input, test.min)
+
+			if id != test.verify || !errors.Is(err, test.err) {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id), err, int64(test.verify), test.err)
+			}
+		})
+	}
+}
+
+func TestParseAny(t *testing.T) {
+	tests := []struct {
+		input  string
+		verify ID
+		err    error
+	}{
+		{"8uyZY2sj3re", ID(305023354946072576), nil},
+		{"305023354946072576", ID(305023354946072576), nil},
+		{"21", ID(21), nil}, // ambiguous, base 54 would be 123
+		{"6vF", ID(123123), nil},
+		{"99999999999999999999", Invalid, &ErrorInvalid}, // too large for both
+		{"8uy!", Invalid, &ErrorInvalidByte},
+		{"", Invalid, &ErrorEmptyInput},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseAny_%s", test.input), func(t *testing.T) {
+			id, err := ParseAny(test.input)
+
+			if id != test.verify || !errors.Is(err, test.err) {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id), err, int64(test.verify), test.err)
+			}
+		})
+	}
+}
+
+func TestParseInt64(t *testing.T) {
+	tests := []string{"21", "8uyZY2sj3re", "EZNmktHEz5H", "xZNmktHEz5H", "8uy!", ""}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseInt64_%s", test), func(t *testing.T) {
+			id, err1 := Parse(test)
+			v, err2 := ParseInt64(test)
+
+			if v != int64(id) || !errors.Is(err2, err1) {
+				t.Errorf("got '%d' (%v), want '%d' (%v)", v, err2, int64(id), err1)
+			}
+		})
+	}
+}
+
 // 4.833 ns/op
 func BenchmarkBaseDecode(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _ = Parse("8FaPRNs8Uks")
 	}
 }
+
+func TestSetAlphabet(t *testing.T) {
+	t.Cleanup(func() {
+		if err := SetAlphabet(DefaultAlphabet); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	id := ID(305023354946072576)
+	old := id.String()
+
+	if err := SetAlphabet("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-_"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := Parse(old); !errors.Is(err, &ErrorInvalidByte) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalidByte)
+	}
+
+	if parsed, err := Parse(id.String()); err != nil || parsed != id || id.String() == old {
+		t.Errorf("got '%v' (%v), want '%v'", parsed, err, id)
+	}
+
+	// Rejected alphabets leave the current one untouched.
+	invalid := []struct {
+		name     string
+		alphabet string
+		err      error
+	}{
+		{"Duplicate", "gg2FcYyTeUr0vsn1Jb9NmLMPuHGhVztRp4f3jDk5Zd6ECaw7AWQKXx", &ErrorAlphabetDuplicate},
+		{"Short", DefaultAlphabet[1:], &ErrorAlphabetLength},
+		{"Long", DefaultAlphabet + "i", &ErrorAlphabetLength},
+		{"Empty", "", &ErrorAlphabetLength},
+	}
+
+	for _, test := range invalid {
+		t.Run(fmt.Sprintf("Test_SetAlphabet_%s", test.name), func(t *testing.T) {
+			if err := SetAlphabet(test.alphabet); !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			} else if parsed, _ := Parse(id.String()); parsed != id {
+				t.Errorf("got '%v', want '%v'", parsed, id)
+			}
+		})
+	}
+
+	for _, valid := range []string{sortableAlphabet, DefaultAlphabet} {
+		if err := SetAlphabet(valid); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	// Rebuilding restores a consistent lookup for the current alphabet.
+	active.Load().decodeMap[id.String()[0]] = 0xFF
+	RebuildDecodeMap()
+
+	if parsed, err := Parse(id.String()); err != nil || parsed != id {
+		t.Errorf("got '%v' (%v), want '%v'", parsed, err, id)
+	}
+}
+
+func TestSetAlphabetConcurrent(t *testing.T) {
+	t.Cleanup(func() {
+		if err := SetAlphabet(DefaultAlphabet); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	id := ID(305023354946072576)
+	want := map[string]bool{id.StringWithCheck(): true}
+
+	_ = SetAlphabet(sortableAlphabet)
+	want[id.StringWithCheck()] = true
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 1000; i++ {
+			_ = SetAlphabet([]string{DefaultAlphabet, sortableAlphabet}[i%2])
+		}
+	}()
+
+	// Each call sees one alphabet as a whole, never a mix of both.
+	for i := 0; i < 10000; i++ {
+		if got := id.StringWithCheck(); !want[got] {
+			t.Fatalf("got '%s', want one of '%v'", got, want)
+		}
+	}
+
+	<-done
+}
+
+func TestSetEncodingMode(t *testing.T) {
+	t.Cleanup(func() {
+		if err := SetEncodingMode(Scrambled); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	ids := []ID{0, 53, 54, 123123, 305023354946072576, 305023354946072577, 9223372036854775807}
+
+	for _, mode := range []EncodingMode{Scrambled, Sortable} {
+		t.Run(fmt.Sprintf("Test_EncodingMode_%d", mode), func(t *testing.T) {
+			if err := SetEncodingMode(mode); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			sorted := true
+			for i, id := range ids {
+				parsed, err := Parse(id.String())
+				if err != nil || parsed != id {
+					t.Errorf("got '%v' (%v), want '%v'", int64(parsed), err, int64(id))
+				}
+
+				if i > 0 && id.String() <= ids[i-1].String() {
+					sorted = false
+				}
+			}
+
+			// Only sortable strings sort like IDs, the scrambled
+			// alphabet is not in ASCII order.
+			if sorted != (mode == Sortable) {
+				t.Errorf("got sorted '%v', want '%v'", sorted, mode == Sortable)
+			}
+
+			if mode == Sortable && len(ID(0).String()) != 11 {
+				t.Errorf("got '%s', want 11 chars", ID(0).String())
+			}
+		})
+	}
+
+	// Unpadded sortable input.
+	if id, err := Parse("1"); err != nil || id != 1 {
+		t.Errorf("got '%v' (%v), want '1'", int64(id), err)
+	}
+
+	if err := SetEncodingMode(EncodingMode(2)); !errors.Is(err, &ErrorInvalidConfig) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalidConfig)
+	}
+}
+
+func TestSetCaseInsensitive(t *testing.T) {
+	t.Cleanup(func() {
+		if err := SetEncodingMode(Scrambled); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	// The default alphabet uses both cases.
+	if err := SetCaseInsensitive(true); !errors.Is(err, &ErrorAlphabetCase) {
+		t.Fatalf("got '%v', want '%v'", err, &ErrorAlphabetCase)
+	}
+
+	if err := SetAlphabet("abcdefghijklmnopqrstuvwxyz0123456789-_.~!$&'()*+,;=:@%"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := SetCaseInsensitive(true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	id := ID(305023354946072576)
+	for _, input := range []string{id.String(), strings.ToUpper(id.String())} {
+		if parsed, err := Parse(input); err != nil || parsed != id {
+			t.Errorf("got '%v' (%v), want '%v'", int64(parsed), err, int64(id))
+		}
+	}
+
+	// Check characters and leading zero digits compare by value.
+	for _, id := range []ID{id, ID(123123), ID(9223372036854775807)} {
+		if parsed, err := ParseChecked(strings.ToUpper(id.StringWithCheck())); err != nil || parsed != id {
+			t.Errorf("got '%v' (%v), want '%v'", int64(parsed), err, int64(id))
+		}
+	}
+
+	raw := []byte{0, 0, 42, 7}
+	if b, err := DecodeBytes(strings.ToUpper(EncodeBytes(raw))); err != nil || !bytes.Equal(b, raw) {
+		t.Errorf("got '%x' (%v), want '%x'", b, err, raw)
+	}
+
+	// Incompatible alphabets are rejected while folding.
+	if err := SetAlphabet("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-_"); !errors.Is(err, &ErrorAlphabetCase) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorAlphabetCase)
+	}
+
+	if err := SetCaseInsensitive(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := Parse(strings.ToUpper(id.String())); !errors.Is(err, &ErrorInvalidByte) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalidByte)
+	}
+}
+
+func TestStringWithCheck(t *testing.T) {
+	tests := []ID{
+		ID(0),
+		ID(123123),
+		ID(305023354946072576),
+		ID(9223372036854775807),
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Check_%d", int64(test)), func(t *testing.T) {
+			checked := test.StringWithCheck()
+			id, err := ParseChecked(checked)
+
+			if len(checked) != len(test.String())+1 {
+				t.Errorf("got '%s', want one char appended to '%s'", checked, test.String())
+			} else if err != nil || id != test {
+				t.Errorf("got '%v' (%v), want '%v'", int64(id), err, int64(test))
+			}
+		})
+	}
+}
+
+func TestParseCheckedTypo(t *testing.T) {
+	checked := ID(305023354946072576).StringWithCheck()
+	alphabet := active.Load().alphabet
+
+	// Every single character substitution must be rejected.
+	for i := 0; i < len(checked); i++ {
+		for j := 0; j < len(alphabet); j++ {
+			if checked[i] == alphabet[j] {
+				continue
+			}
+
+			typo := checked[:i] + string(alphabet[j]) + checked[i+1:]
+			if id, err := ParseChecked(typo); err == nil {
+				t.Fatalf("got '%v' for typo '%s' of '%s', want error", int64(id), typo, checked)
+			}
+		}
+	}
+
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"", &ErrorInvalid},
+		{"8", &ErrorInvalid},
+		{"8uyZY2sj3re", &ErrorChecksumMismatch},
+		{"8uyZY2sj3re!", &ErrorInvalidByte},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseChecked_%s", test.input), func(t *testing.T) {
+			if _, err := ParseChecked(test.input); !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			}
+		})
+	}
+}
+
+func TestEncodedLen(t *testing.T) {
+	tests := []struct {
+		id     ID
+		verify int
+	}{
+		{Invalid, 0},
+		{ID(0), 1},
+		{ID(53), 1},
+		{ID(54), 2},
+		{ID(2915), 2},
+		{ID(2916), 3},
+		{minFullWidth - 1, 10},
+		{minFullWidth, 11},
+		{ID(305023354946072576), 11},
+		{ID(9223372036854775807), 11},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_EncodedLen_%d", int64(test.id)), func(t *testing.T) {
+			if got := test.id.EncodedLen(); got != test.verify || got != len(test.id.String()) {
+				t.Errorf("got '%d', want '%d'", got, test.verify)
+			}
+		})
+	}
+
+	if err := SetEncodingMode(Sortable); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := SetEncodingMode(Scrambled); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	// Sortable pads every ID to full width.
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_EncodedLen_Sortable_%d", int64(test.id)), func(t *testing.T) {
+			if got := test.id.EncodedLen(); got != len(test.id.String()) {
+				t.Errorf("got '%d', want '%d'", got, len(test.id.String()))
+			}
+		})
+	}
+
+	if got := EncodedLenForTime(time.UnixMilli(Epoch)); got != 11 {
+		t.Errorf("got '%d', want '11'", got)
+	}
+}
+
+func TestEncodeBytes(t *testing.T) {
+	tests := []struct {
+		b      []byte
+		verify string
+	}{
+		{[]byte{}, ""},
+		{[]byte{0}, "g"},
+		{[]byte{0, 0, 1}, "gg8"},
+		{[]byte{0x01, 0xe0, 0xf3}, "6vF"}, // 123123
+		{[]byte{0x04, 0x3b, 0xa9, 0x20, 0x0f, 0xc2, 0x30, 0x00}, "8uyZY2sj3re"}, // 305023354946072576
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_EncodeBytes_%x", test.b), func(t *testing.T) {
+			encoded := EncodeBytes(test.b)
+			decoded, err := DecodeBytes(encoded)
+
+			if encoded != test.verify {
+				t.Errorf("got '%s', want '%s'", encoded, test.verify)
+			} else if err != nil || !bytes.Equal(decoded, test.b) {
+				t.Errorf("got '%x' (%v), want '%x'", decoded, err, test.b)
+			}
+		})
+	}
+}
+
+func TestEncodeBytesRandom(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+
+	for n := 0; n < 64; n++ {
+		for k := 0; k < 20; k++ {
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = byte(r.UintN(256))
+			}
+
+			// Exercises leading zeros.
+			for i := 0; i < k%4 && i < n; i++ {
+				b[i] = 0
+			}
+
+			decoded, err := DecodeBytes(EncodeBytes(b))
+			if err != nil || !bytes.Equal(decoded, b) {
+				t.Fatalf("got '%x' (%v), want '%x'", decoded, err, b)
+			}
+		}
+	}
+
+	// Long inputs need every digit of the size estimate.
+	for _, n := range []int{1000, 5000, 10000} {
+		b := bytes.Repeat([]byte{0xFF}, n)
+		random := make([]byte, n)
+		for i := range random {
+			random[i] = byte(r.UintN(256))
+		}
+
+		for _, input := range [][]byte{b, random} {
+			decoded, err := DecodeBytes(EncodeBytes(input))
+			if err != nil || !bytes.Equal(decoded, input) {
+				t.Fatalf("got %d bytes (%v), want round trip of %d bytes", len(decoded), err, n)
+			}
+		}
+	}
+
+	if _, err := DecodeBytes("8uy!"); !errors.Is(err, &ErrorInvalidByte) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalidByte)
+	}
+}
+
+func TestShortTag(t *testing.T) {
+	id := ID(305023354946072576)
+	later := id + 1000<<(bitsMachineID+bitsMachineSequence)
+
+	if tag := id.ShortTag(); len(tag) != 4 || tag != id.ShortTag() {
+		t.Errorf("got '%s' and '%s', want the same 4 chars", tag, id.ShortTag())
+	} else if tag != later.ShortTag() {
+		t.Errorf("got '%s' and '%s', want timestamp independence", tag, later.ShortTag())
+	}
+
+	// All machine ids and sequences yield distinct tags.
+	seen := make(map[string]bool)
+	for machineId := int64(0); machineId <= bitMapMachineId; machineId++ {
+		for sequence := int64(0); sequence < 8; sequence++ {
+			tag := IDFromEpochMillis(1, machineId, sequence).ShortTag()
+			if seen[tag] {
+				t.Fatalf("duplicate tag '%s' for machine %d sequence %d", tag, machineId, sequence)
+			}
+
+			seen[tag] = true
+		}
+	}
+}
+
+func TestPrefixed(t *testing.T) {
+	tests := []struct {
+		prefix string
+		id     ID
+		verify string
+	}{
+		{"ord", ID(305023354946072576), "ord_8uyZY2sj3re"},
+		{"cus", ID(123123), "cus_6vF"},
+		{"line_item", ID(123), "line_item_21"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Prefixed_%s", test.verify), func(t *testing.T) {
+			encoded := test.id.Prefixed(test.prefix)
+			prefix, id, err := ParsePrefixed(encoded)
+
+			if encoded != test.verify {
+				t.Errorf("got '%s', want '%s'", encoded, test.verify)
+			} else if err != nil || prefix != test.prefix || id != test.id {
+				t.Errorf("got '%s' '%v' (%v), want '%s' '%v'", prefix, int64(id), err, test.prefix, int64(test.id))
+			}
+		})
+	}
+
+	if encoded := Invalid.Prefixed("ord"); encoded != "" {
+		t.Errorf("got '%s', want ''", encoded)
+	}
+}
+
+func TestVersionedString(t *testing.T) {
+	for version := byte(0); version < 8; version++ {
+		t.Run(fmt.Sprintf("Test_Versioned_%d", version), func(t *testing.T) {
+			id := ID(305023354946072576)
+			encoded := id.VersionedString(version)
+			v, parsed, err := ParseVersioned(encoded)
+
+			if err != nil || v != version || parsed != id {
+				t.Errorf("got '%d' '%v' (%v), want '%d' '%v'", v, int64(parsed), err, version, int64(id))
+			} else if active.Load().decodeMap[encoded[0]] != 0xFF {
+				t.Errorf("got marker '%c' within the alphabet", encoded[0])
+			}
+		})
+	}
+
+	if got := ID(305023354946072576).VersionedString(0); got != "B8uyZY2sj3re" {
+		t.Errorf("got '%s', want 'B8uyZY2sj3re'", got)
+	}
+
+	if got := ID(123).VersionedString(8); got != "" {
+		t.Errorf("got '%s', want ''", got)
+	}
+}
+
+func TestParseVersionedInvalid(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"8uyZY2sj3re", &ErrorUnknownVersion},
+		{"Z8uyZY2sj3re", &ErrorUnknownVersion},
+		{"B", &ErrorEmptyInput},
+		{"", &ErrorEmptyInput},
+		{"B8uy!", &ErrorInvalidByte},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseVersioned_%s", test.input), func(t *testing.T) {
+			if _, id, err := ParseVersioned(test.input); id != Invalid || !errors.Is(err, test.err) {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id), err, int64(Invalid), test.err)
+			}
+		})
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		id     ID
+		ext    string
+		verify string
+	}{
+		{ID(305023354946072576), "json", "8uyZY2sj3re.json"},
+		{ID(305023354946072576), "tar.gz", "8uyZY2sj3re.tar.gz"},
+		{ID(305023354946072576), "", "8uyZY2sj3re"},
+		{ID(123123), "csv", "6vF.csv"},
+		{Invalid, "json", ""},
+		{ID(123123), ".json", ""},
+		{ID(123123), "json.", ""},
+		{ID(123123), "tar..gz", ""},
+		{ID(123123), "../etc", ""},
+		{ID(123123), "js/on", ""},
+		{ID(123123), "js on", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_FileName_%d_%s", int64(test.id), test.ext), func(t *testing.T) {
+			if got := test.id.FileName(test.ext); got != test.verify {
+				t.Errorf("got '%s', want '%s'", got, test.verify)
+			}
+		})
+	}
+}
+
+func TestParsePrefixedInvalid(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"8uyZY2sj3re", &ErrorInvalidPrefix},
+		{"_8uyZY2sj3re", &ErrorInvalidPrefix},
+		{"ord_", &ErrorInvalid},
+		{"ord_8uy!", &ErrorInvalidByte},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParsePrefixed_%s", test.input), func(t *testing.T) {
+			if _, id, err := ParsePrefixed(test.input); !errors.Is(err, test.err) || id != Invalid {
+				t.Errorf("got '%v' (%v), want '%v'", int64(id), err, test.err)
+			}
+		})
+	}
+}
@@ -9,10 +9,38 @@ type SnowflakeError struct {
 }
 
 var (
-	ErrorInvalid         = SnowflakeError{0x0, "invalid id"}
-	ErrorInvalidByte     = SnowflakeError{0x1, "invalid byte detected"}
-	ErrorInvalidJson     = SnowflakeError{0x2, "invalid json format"}
-	ErrorEncodeMapLength = SnowflakeError{0x100, "encode map is not long enough"}
+	ErrorInvalid             = SnowflakeError{0x0, "invalid id"}
+	ErrorInvalidByte         = SnowflakeError{0x1, "invalid byte detected"}
+	ErrorInvalidJson         = SnowflakeError{0x2, "invalid json format"}
+	ErrorChecksumMismatch    = SnowflakeError{0x3, "checksum mismatch"}
+	ErrorReservedBitSet      = SnowflakeError{0x4, "reserved high bit is set"}
+	ErrorInvalidCursor       = SnowflakeError{0x5, "invalid cursor"}
+	ErrorInvalidPrefix       = SnowflakeError{0x6, "missing or empty prefix"}
+	ErrorUnknownContinent    = SnowflakeError{0x7, "continent has no registered regions"}
+	ErrorInvalidSequence     = SnowflakeError{0x8, "sequence out of range"}
+	ErrorOutOfOrder          = SnowflakeError{0x9, "id is older than tolerated"}
+	ErrorEmptyInput          = SnowflakeError{0xa, "empty input"}
+	ErrorMissingParameter    = SnowflakeError{0xb, "missing parameter"}
+	ErrorUnknownVersion      = SnowflakeError{0xc, "unknown version marker"}
+	ErrorEncodeMapLength     = SnowflakeError{0x100, "encode map is not long enough"}
+	ErrorAlphabetLength      = SnowflakeError{0x101, "alphabet must be exactly 54 bytes"}
+	ErrorAlphabetDuplicate   = SnowflakeError{0x102, "alphabet contains duplicate bytes"}
+	ErrorAlphabetCase        = SnowflakeError{0x103, "alphabet contains both cases of a letter"}
+	ErrorInvalidMachineId    = SnowflakeError{0x200, "unable to determine proper machine id"}
+	ErrorClockBackwards      = SnowflakeError{0x201, "attempted to generate snowflake id of the past"}
+	ErrorNotIncreasing       = SnowflakeError{0x202, "generated ids are not strictly increasing"}
+	ErrorInvalidTag          = SnowflakeError{0x203, "tag does not fit into reserved bits"}
+	ErrorInvalidConfig       = SnowflakeError{0x204, "invalid generator config"}
+	ErrorNoFreeMachineId     = SnowflakeError{0x205, "no free machine id left"}
+	ErrorInvalidEpoch        = SnowflakeError{0x206, "epoch must not be in the future"}
+	ErrorInvalidState        = SnowflakeError{0x207, "invalid generator state"}
+	ErrorInvalidBorrow       = SnowflakeError{0x208, "unable to borrow machine slots"}
+	ErrorSequenceExhausted   = SnowflakeError{0x209, "sequence exhausted for current millisecond"}
+	ErrorInvalidLayout       = SnowflakeError{0x20a, "invalid snowflake bit length"}
+	ErrorMachineIdAllocation = SnowflakeError{0x20b, "unable to allocate machine id"}
+	ErrorClosed              = SnowflakeError{0x20c, "generator is closed"}
+	ErrorEpochMismatch       = SnowflakeError{0x20d, "epoch does not match expected value"}
+	ErrorMachineIdInUse      = SnowflakeError{0x20e, "machine id is already in use"}
 )
 
 func (e *SnowflakeError) Error() string {
@@ -0,0 +1,36 @@
+package snowflake
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// Generation statistics across all generators, only counted once
+// published, since every generator writing them contends on the hot path.
+var (
+	statsEnabled      atomic.Bool
+	statGenerated     atomic.Int64
+	statExhausted     atomic.Int64
+	statLastTimestamp atomic.Int64
+)
+
+// Publishes generation statistics via `expvar`, making them available
+// at `/debug/vars`. Registers `<prefix>.generated`, `<prefix>.exhausted`
+// (sequence exhaustions) and `<prefix>.last_timestamp` (Unix milliseconds).
+// Publishing is opt-in, and like `expvar.Publish` panics if called twice
+// with the same prefix. Only IDs minted after publishing are counted.
+func PublishExpvar(prefix string) {
+	statsEnabled.Store(true)
+
+	expvar.Publish(prefix+".generated", expvar.Func(func() any {
+		return statGenerated.Load()
+	}))
+
+	expvar.Publish(prefix+".exhausted", expvar.Func(func() any {
+		return statExhausted.Load()
+	}))
+
+	expvar.Publish(prefix+".last_timestamp", expvar.Func(func() any {
+		return statLastTimestamp.Load()
+	}))
+}
@@ -0,0 +1,64 @@
+package snowflake
+
+import (
+	"expvar"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	// Publishing twice panics, e.g. with -count > 1.
+	if expvar.Get("snowflake_test.generated") == nil {
+		PublishExpvar("snowflake_test")
+	}
+
+	read := func(name string) int64 {
+		v, _ := strconv.ParseInt(expvar.Get("snowflake_test."+name).String(), 10, 64)
+		return v
+	}
+
+	generated := read("generated")
+	exhausted := read("exhausted")
+
+	g, _ := NewGenerator("arn", 35)
+	var id ID
+
+	for i := 0; i < 5000; i++ {
+		id, _ = g.Generate()
+	}
+
+	// Exhaust the sequence of the current millisecond.
+	g.sequence = bitMapMachineSequence
+	g.previous = time.Since(g.epoch).Milliseconds()
+	id, _ = g.Generate()
+
+	if got := read("generated") - generated; got < 5000 {
+		t.Errorf("got %d generated, want at least 5000", got)
+	}
+
+	if got := read("exhausted") - exhausted; got < 1 {
+		t.Errorf("got %d exhaustions, want at least 1", got)
+	}
+
+	if got := read("last_timestamp"); got < id.Time() {
+		t.Errorf("got last timestamp '%d', want at least '%d'", got, id.Time())
+	}
+}
+
+func TestExpvarUnpublished(t *testing.T) {
+	enabled := statsEnabled.Load()
+	statsEnabled.Store(false)
+	t.Cleanup(func() { statsEnabled.Store(enabled) })
+
+	generated := statGenerated.Load()
+
+	g, _ := NewGenerator("arn", 35)
+	for i := 0; i < 100; i++ {
+		_, _ = g.Generate()
+	}
+
+	if got := statGenerated.Load() - generated; got != 0 {
+		t.Errorf("got %d generated, want 0 before publishing", got)
+	}
+}
@@ -0,0 +1,96 @@
+package snowflake
+
+// Scans for the first run of alphabet characters that decodes to
+// a plausible snowflake, e.g. `8uyZY2sj3re` in the log line
+// `request_id=8uyZY2sj3re method=GET`. Plausibility is determined by
+// `LooksLikeSnowflake`, since short words consisting of alphabet
+// characters are valid base 54 as well.
+func ExtractFirst(s string) (ID, bool) {
+	first := Invalid
+	extractEach(s, func(id ID) bool {
+		first = id
+		return false
+	})
+
+	return first, first != Invalid
+}
+
+// Scans for all runs of alphabet characters that decode to plausible
+// snowflakes like `ExtractFirst`, in order of appearance, e.g. to
+// correlate multiple IDs of a single log line. Returns nil if none.
+func ExtractAll(s string) []ID {
+	var ids []ID
+	extractEach(s, func(id ID) bool {
+		ids = append(ids, id)
+		return true
+	})
+
+	return ids
+}
+
+// Calls fn for every maximal run of alphabet characters that decodes to
+// a plausible snowflake, until fn returns false.
+func extractEach(s string, fn func(ID) bool) {
+	decodeMap := &active.Load().decodeMap
+
+	for i := 0; i < len(s); {
+		if decodeMap[s[i]] == 0xFF {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(s) && decodeMap[s[j]] != 0xFF {
+			j++
+		}
+
+		if id, ok := extractRun(s[i:j]); ok && !fn(id) {
+			return
+		}
+
+		i = j
+	}
+}
+
+// Decodes a maximal run of alphabet characters if it looks like a
+// snowflake. Longer or overflowing runs are rejected as a whole, since
+// decoding a prefix would make up IDs that are not in the input.
+func extractRun(run string) (ID, bool) {
+	if len(run) > 11 {
+		return Invalid, false
+	}
+
+	id, err := decode54([]byte(run))
+
+	return id, err == nil && LooksLikeSnowflake(int64(id))
+}
+
+// Decodes the longest leading run of alphabet bytes that does not
+// overflow, at most 11, and returns the remaining input, e.g. for
+// tokenizers consuming `8uyZY2sj3re,next`. The remainder may start with
+// alphabet bytes if the run was longer than a snowflake.
+func ParsePrefix(b []byte) (id ID, rest []byte, err error) {
+	decodeMap := &active.Load().decodeMap
+
+	n := 0
+	for n < len(b) && n < 11 && decodeMap[b[n]] != 0xFF {
+		n++
+	}
+
+	if n == 0 {
+		if len(b) == 0 {
+			return Invalid, b, &ErrorEmptyInput
+		}
+
+		return Invalid, b, &ErrorInvalidByte
+	}
+
+	// Only 11 chars can overflow, 10 never do.
+	id, err = decode54(b[:n])
+	if err != nil {
+		n--
+		id, err = decode54(b[:n])
+	}
+
+	return id, b[n:], err
+}
@@ -0,0 +1,90 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestExtractFirst(t *testing.T) {
+	tests := []struct {
+		line   string
+		verify ID
+		ok     bool
+	}{
+		{"request_id=8uyZY2sj3re method=GET", ID(305023354946072576), true},
+		{"8uyZY2sj3re", ID(305023354946072576), true},
+		{"[8uyZY2sj3re]", ID(305023354946072576), true},
+		{"id=8uyZY2sj3reX", Invalid, false}, // longer run, no prefix
+		{"x8uyZY2sj3re", Invalid, false},
+		{"token=Abcdefghjkmnpq", Invalid, false},
+		{"xZNmktHEz5H 8uyZY2sj3re", ID(305023354946072576), true}, // overflow skipped
+		{"user=efUzLtM5yvu request_id=8uyZY2sj3re", ID(305023354946072576), true},
+		{"request_id=6vF method=GET", Invalid, false},
+		{"", Invalid, false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ExtractFirst_%s", test.line), func(t *testing.T) {
+			id, ok := ExtractFirst(test.line)
+
+			if id != test.verify || ok != test.ok {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id), ok, int64(test.verify), test.ok)
+			}
+		})
+	}
+}
+
+func TestExtractAll(t *testing.T) {
+	first, second := ID(305023354946072576), ID(305023354946072577)
+
+	tests := []struct {
+		line   string
+		verify []ID
+	}{
+		{fmt.Sprintf("trace=%s parent=%s method=GET", first, second), []ID{first, second}},
+		{fmt.Sprintf("%s,%s,%s", second, first, second), []ID{second, first, second}},
+		{fmt.Sprintf("zzzzzzzzzzzzzzzz %s 6vF", first), []ID{first}}, // overflowing run
+		{"request_id=6vF method=GET", nil},
+		{"token=Abcdefghjkmnpq", nil},
+		{"x8uyZY2sj3re", nil},
+		{fmt.Sprintf("xZNmktHEz5H %s", second), []ID{second}}, // overflow
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ExtractAll_%s", test.line), func(t *testing.T) {
+			if ids := ExtractAll(test.line); !slices.Equal(ids, test.verify) {
+				t.Errorf("got '%v', want '%v'", ids, test.verify)
+			}
+		})
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		input  string
+		verify ID
+		rest   string
+		err    error
+	}{
+		{"8uyZY2sj3re,next", ID(305023354946072576), ",next", nil},
+		{"8uyZY2sj3re", ID(305023354946072576), "", nil},
+		{"6vF 8uyZY2sj3re", ID(123123), " 8uyZY2sj3re", nil},
+		{"8uyZY2sj3reX", ID(305023354946072576), "X", nil},      // longer run
+		{"xZNmktHEz5H;", ParseTrusted("xZNmktHEz5"), "H;", nil}, // overflow, shortened
+		{",next", Invalid, ",next", &ErrorInvalidByte},
+		{"", Invalid, "", &ErrorEmptyInput},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParsePrefix_%s", test.input), func(t *testing.T) {
+			id, rest, err := ParsePrefix([]byte(test.input))
+
+			if id != test.verify || string(rest) != test.rest || !errors.Is(err, test.err) {
+				t.Errorf("got '%v' '%s' (%v), want '%v' '%s' (%v)", int64(id), rest, err, int64(test.verify), test.rest, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,666 @@
+package snowflake
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Generator mints snowflake IDs for a single machine id and is safe
+// for concurrent use. The package level `Generate` uses a default
+// generator configured through `SetMachineId`.
+type Generator struct {
+	mutex     locker
+	now       func() time.Time
+	epoch     time.Time
+	machineId int64
+	sequence  int64
+	previous  int64
+
+	// First sequence number of the current millisecond.
+	start       int64
+	randomStart bool
+
+	// Constant carried in the low `bitsTag` sequence bits.
+	tag    int64
+	tagged bool
+
+	// Number of IDs minted in the current millisecond.
+	issued int64
+	guard  bool
+
+	// Machine index bits reallocated to the sequence, see `WithMachineBits`.
+	freedBits int64
+
+	// Ignore clock anomalies, see `WithMonotonicFallback`.
+	fallback bool
+
+	// Top sequence bit selects a stream, see `GenerateStream`.
+	streaming bool
+
+	// Calls left reporting saturation, see `IsSaturated`.
+	saturated atomic.Int64
+
+	// Hook for the first minted ID, see `OnFirstGenerate`.
+	first  func(ID)
+	minted bool
+
+	// Lifecycle, see `Close`.
+	closed  bool
+	done    chan struct{}
+	release func() error
+
+	// Machine id is pinned, see `NewRegisteredGenerator`.
+	registered bool
+}
+
+// Mutual exclusion of a generator, either a `sync.Mutex` or a spinlock,
+// see `WithSpinLock`.
+type locker struct {
+	spin  bool
+	state atomic.Bool
+	mutex sync.Mutex
+}
+
+func (l *locker) Lock() {
+	if !l.spin {
+		l.mutex.Lock()
+		return
+	}
+
+	for !l.state.CompareAndSwap(false, true) {
+		runtime.Gosched()
+	}
+}
+
+func (l *locker) Unlock() {
+	if !l.spin {
+		l.mutex.Unlock()
+		return
+	}
+
+	l.state.Store(false)
+}
+
+// Source mints snowflake IDs. Accepting a Source instead of calling
+// `Generate` directly allows injecting a deterministic fake in tests,
+// see package `snowflaketest`.
+type Source interface {
+	Generate() (ID, error)
+}
+
+// Returns the generator backing the package level functions.
+func Default() *Generator {
+	return defaultGenerator
+}
+
+// Creates a generator for the given region and machine index,
+// applying the given options in order.
+// ATTENTION: If more than one generator is using the same
+// machine id in parallel, then the uniqueness of any
+// snowflake ID can _NOT_ be guaranteed.
+func NewGenerator(region string, index int64, opts ...Option) (*Generator, error) {
+	machineId, err := newMachineId(region, index)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Generator{now: time.Now, epoch: epoch, machineId: machineId}
+
+	for _, opt := range opts {
+		if err := opt(g); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// Sets the machine id like the package level `SetMachineId`, but returns
+// `ErrorInvalidMachineId` instead of panicking. Respects `WithMachineBits`.
+// Returns `ErrorInvalidConfig` for generators of `NewRegisteredGenerator`,
+// whose machine id is fixed by the registration.
+func (g *Generator) SetMachineId(region string, index int64) error {
+	id, err := newMachineId(region, index)
+	if err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.registered {
+		return fmt.Errorf("%w: machine id %d is registered", &ErrorInvalidConfig, g.machineId)
+	}
+
+	machineId, freedBits := g.machineId, g.freedBits
+	g.machineId, g.freedBits = id, 0
+
+	if freedBits != 0 {
+		if err := WithMachineBits(bitsMachineID - 3 - freedBits)(g); err != nil {
+			g.machineId, g.freedBits = machineId, freedBits
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Creates a generator with a random 9 bit machine id, for single node
+// tools that do not care about machine assignment. ATTENTION: Unsuitable
+// for multi-node deployments, two generators may pick the same machine
+// id. The continent bits are random as well.
+func NewStandaloneGenerator() *Generator {
+	return &Generator{now: time.Now, epoch: epoch, machineId: rand.Int64N(bitMapMachineId + 1)}
+}
+
+// Creates a generator whose machine index is obtained from an external
+// allocator, e.g. backed by etcd or Consul, decoupling this package from
+// any specific coordination system. The index is validated like in
+// `NewGenerator`. Use `WithRelease` to hand it back on `Close`.
+func NewGeneratorFunc(region string, alloc func() (int64, error), opts ...Option) (*Generator, error) {
+	index, err := alloc()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", &ErrorMachineIdAllocation, err)
+	}
+
+	return NewGenerator(region, index, opts...)
+}
+
+// Creates a generator from a generic key/value config, e.g. for
+// dependency injection frameworks. Supported keys are `region`,
+// `machine` (index within the continent) and the optional `epoch`
+// in Unix milliseconds. IDs minted with a custom epoch must be
+// decoded with that same epoch.
+func NewGeneratorFromMap(cfg map[string]string) (*Generator, error) {
+	for key := range cfg {
+		if key != "region" && key != "machine" && key != "epoch" {
+			return nil, fmt.Errorf("%w: unknown key %q", &ErrorInvalidConfig, key)
+		}
+	}
+
+	region, ok := cfg["region"]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing key \"region\"", &ErrorInvalidConfig)
+	}
+
+	machine, ok := cfg["machine"]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing key \"machine\"", &ErrorInvalidConfig)
+	}
+
+	index, err := strconv.ParseInt(machine, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: machine %q is not an integer", &ErrorInvalidConfig, machine)
+	}
+
+	var opts []Option
+	if value, ok := cfg["epoch"]; ok {
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: epoch %q is not an integer", &ErrorInvalidConfig, value)
+		}
+
+		opts = append(opts, WithEpoch(time.UnixMilli(ms)))
+	}
+
+	g, err := NewGenerator(region, index, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: region %q with machine %d: %w", &ErrorInvalidConfig, region, index, err)
+	}
+
+	return g, nil
+}
+
+// Version of the `MarshalState` format.
+const stateVersion byte = 1
+
+// Serializes the generator state, i.e. machine id, layout, epoch and latest
+// timestamp, to hand it off to a new process, see `RestoreGenerator`.
+func (g *Generator) MarshalState() []byte {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	b := make([]byte, 1, 26)
+	b[0] = stateVersion
+	b = binary.BigEndian.AppendUint64(b, uint64(g.machineId))
+	b = binary.BigEndian.AppendUint64(b, uint64(g.epoch.UnixMilli()))
+	b = binary.BigEndian.AppendUint64(b, uint64(g.previous))
+	b = append(b, byte(g.freedBits))
+
+	return b
+}
+
+// Restores a generator from `MarshalState`, applying the given options
+// afterwards. The restored generator never mints an ID older than the
+// latest one of the previous generator, and resumes in the next millisecond.
+func RestoreGenerator(b []byte, opts ...Option) (*Generator, error) {
+	if len(b) != 26 || b[0] != stateVersion {
+		return nil, &ErrorInvalidState
+	}
+
+	machineId := int64(binary.BigEndian.Uint64(b[1:]))
+	epochMs := int64(binary.BigEndian.Uint64(b[9:]))
+	previous := int64(binary.BigEndian.Uint64(b[17:]))
+	freedBits := int64(b[25])
+
+	if freedBits > bitsMachineID-3 || machineId < 0 || machineId > bitMapMachineId>>freedBits || previous < 0 {
+		return nil, &ErrorInvalidState
+	}
+
+	g := &Generator{now: time.Now, epoch: monotonicEpoch(epochMs), machineId: machineId, freedBits: freedBits}
+
+	// Mark the latest millisecond as exhausted, since the
+	// previous generator may have used any of its sequences.
+	g.previous = previous
+	g.sequence = g.start - 1
+
+	for _, opt := range opts {
+		if err := opt(g); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// Generates a unique snowflake id.
+func (g *Generator) Generate() (ID, error) {
+	return g.generate(true, -1)
+}
+
+// Generates a unique snowflake id like `Generate`, but returns
+// `ErrorSequenceExhausted` instead of waiting for the next millisecond
+// if the sequence of the current one is exhausted.
+func (g *Generator) TryGenerate() (ID, error) {
+	return g.generate(false, -1)
+}
+
+// Number of calls after a sequence exhaustion reported by `IsSaturated`.
+const saturationWindow int64 = 8
+
+// Reports whether one of the last few calls exhausted the sequence of
+// its millisecond, i.e. had to wait or failed with
+// `ErrorSequenceExhausted`, e.g. to shed load before latency spikes.
+// Lock-free, hence cheap enough to check on every request.
+func (g *Generator) IsSaturated() bool {
+	return g.saturated.Load() > 0
+}
+
+// Registers a hook invoked exactly once with the first successfully
+// minted ID, e.g. to log machine id and continent on startup. Has no
+// effect once an ID was minted. The hook runs outside of the lock, so
+// that it may use the generator.
+func (g *Generator) OnFirstGenerate(fn func(ID)) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.first = fn
+}
+
+// Returns the hook of `OnFirstGenerate` if no ID was minted before the
+// one just minted, nil otherwise. Expects the caller to hold the lock,
+// such that the hook receives the actual first ID.
+func (g *Generator) takeFirst() func(ID) {
+	if g.minted {
+		return nil
+	}
+
+	g.minted = true
+	return g.first
+}
+
+// Generates a unique snowflake id for one of two logical streams, 0 or 1,
+// carried in the top sequence bit. Both streams share the sequence, hence
+// they never collide and each stays within half the sequence space.
+// ATTENTION: The shared sequence halves the total throughput as well,
+// both streams together mint at most 2048 IDs per millisecond, not 2048
+// each. Like `SetTag`, switching between `Generate` and `GenerateStream`
+// resumes in the next millisecond.
+func (g *Generator) GenerateStream(stream int) (ID, error) {
+	if stream != 0 && stream != 1 {
+		return Invalid, fmt.Errorf("%w: stream %d, want 0 or 1", &ErrorInvalidSequence, stream)
+	}
+
+	return g.generate(true, int64(stream))
+}
+
+// Mints the next ID, stream is negative unless called by `GenerateStream`.
+func (g *Generator) generate(wait bool, stream int64) (ID, error) {
+	g.mutex.Lock()
+
+	id, err := g.next(wait, stream)
+
+	var first func(ID)
+	if err == nil {
+		first = g.takeFirst()
+	}
+
+	g.mutex.Unlock()
+
+	if first != nil {
+		first(id)
+	}
+
+	return id, err
+}
+
+// Fills the slice with consecutive IDs under a single lock acquisition,
+// waiting for the next millisecond whenever the sequence is exhausted.
+func (g *Generator) fill(ids []ID) error {
+	g.mutex.Lock()
+
+	for i := range ids {
+		id, err := g.next(true, -1)
+		if err != nil {
+			g.mutex.Unlock()
+			return err
+		}
+
+		ids[i] = id
+	}
+
+	first := g.takeFirst()
+	g.mutex.Unlock()
+
+	if first != nil {
+		first(ids[0])
+	}
+
+	return nil
+}
+
+// Mints the next ID, expects the caller to hold the lock.
+func (g *Generator) next(wait bool, stream int64) (ID, error) {
+	if g.closed {
+		return Invalid, &ErrorClosed
+	} else if g.now == nil {
+		return Invalid, fmt.Errorf("%w: zero value generator, see `NewGenerator`", &ErrorInvalidConfig)
+	}
+
+	now := g.now().Sub(g.epoch).Milliseconds()
+	mask := g.sequenceMask()
+
+	// IDs minted before a layout change may collide with later ones.
+	streaming := stream >= 0
+	switched := g.streaming != streaming
+
+	if streaming {
+		mask >>= 1
+	}
+
+	// Continue from the latest timestamp instead of failing.
+	if g.fallback && now < g.previous {
+		now = g.previous
+	}
+
+	exhausted := false
+	if now == g.previous && ((g.sequence+1)&mask == g.start || switched) {
+		// Reached max squence number 2^{BitsMachineSequence}.
+		// Wait for the next millisecond.
+		if !switched {
+			if statsEnabled.Load() {
+				statExhausted.Add(1)
+			}
+
+			g.saturated.Store(saturationWindow)
+			exhausted = true
+		}
+
+		if g.fallback {
+			// Advance logically instead of waiting for the clock.
+			now = g.previous + 1
+		} else if !wait {
+			return Invalid, &ErrorSequenceExhausted
+		} else {
+			for now <= g.previous {
+				now = g.now().Sub(g.epoch).Milliseconds()
+			}
+		}
+	}
+
+	if now > g.previous {
+		// Reset machine sequence for new millisecond
+		g.start = 0
+		if g.randomStart {
+			g.start = rand.Int64N(mask + 1)
+		}
+
+		g.sequence = g.start - 1
+		g.issued = 0
+	} else if now < g.previous {
+		// Avoid potential duplicates
+		return Invalid, &ErrorClockBackwards
+	}
+
+	g.streaming = streaming
+
+	// Safety net in case the exhaustion check above is ever bypassed,
+	// the sequence would silently wrap to an already used value.
+	if g.guard && g.issued > mask {
+		return Invalid, &ErrorSequenceExhausted
+	}
+
+	g.issued++
+
+	if !exhausted && g.saturated.Load() > 0 {
+		g.saturated.Add(-1)
+	}
+
+	// Increment machine sequence
+	g.sequence = (g.sequence + 1) & mask
+
+	// Update latest ID timestamp
+	g.previous = now
+
+	if statsEnabled.Load() {
+		statGenerated.Add(1)
+		statLastTimestamp.Store(g.epoch.UnixMilli() + now)
+	}
+
+	sequence := g.sequence
+	if streaming {
+		sequence |= stream * (mask + 1)
+	}
+
+	if g.tagged {
+		sequence = sequence<<bitsTag | g.tag
+	}
+
+	// Return snowflake
+	return ID(now<<(bitsMachineID+bitsMachineSequence) |
+		(g.machineId << (bitsMachineSequence + g.freedBits)) |
+		sequence), nil
+}
+
+// Returns the time left until the timestamp overflows for the epoch
+// of this generator. Negative once the ceiling has passed.
+func (g *Generator) TimeUntilOverflow() time.Duration {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.epoch.Add(time.Duration(1<<bitsTimestamp) * time.Millisecond).Sub(g.now())
+}
+
+// Returns the bitmap of the sequence counter, which grows by the
+// freed machine index bits and shrinks by `bitsTag` bits when a tag is set.
+func (g *Generator) sequenceMask() int64 {
+	mask := int64(1)<<(bitsMachineSequence+g.freedBits) - 1
+	if g.tagged {
+		return mask >> bitsTag
+	}
+
+	return mask
+}
+
+// Splits a snowflake into its raw bit fields like `ID.Decompose`, but
+// according to the layout of this generator, see `WithMachineBits`.
+func (g *Generator) Decompose(id ID) (timestamp, machineId, sequence int64) {
+	bitsSequence := bitsMachineSequence + g.freedBits
+
+	return id.EpochMillis(),
+		(int64(id) >> bitsSequence) & (bitMapMachineId >> g.freedBits),
+		int64(id) & (1<<bitsSequence - 1)
+}
+
+// Starts the sequence of each new millisecond at a random offset,
+// wrapping around the 12 bit space. This reduces the chance of
+// colliding with IDs minted just before a restart within the same
+// millisecond, at the cost of IDs no longer being sortable by
+// sequence within a millisecond: consecutive IDs may decrease, also
+// those of `Ring` and `WithMonotonicFallback`. They remain unique.
+func (g *Generator) SetRandomSequenceStart(enabled bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.randomStart = enabled
+}
+
+// Reserves the low 4 sequence bits for a caller supplied constant,
+// e.g. a shard hint, which is OR'd into every generated ID and can
+// be extracted with `ID.Tag`. This reduces the sequence space to 256
+// IDs per millisecond. Generation resumes in the next millisecond.
+func (g *Generator) SetTag(tag int64) error {
+	if tag < 0 || tag > bitMapTag {
+		return &ErrorInvalidTag
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.tag = tag
+	g.tagged = true
+
+	// Mark the current millisecond as exhausted, since IDs minted
+	// before the layout change may collide with tagged ones. A random
+	// start beyond the smaller mask would never be reached again.
+	g.start &= g.sequenceMask()
+	g.sequence = g.start - 1
+
+	return nil
+}
+
+// Returns an unbuffered channel emitting at most `rate` IDs per `d`,
+// e.g. for load testing downstream systems. The channel is closed once
+// the context is done or the generator closed. IDs failing to generate
+// are skipped. The channel is closed right away for a non-positive rate
+// or duration, or if the interval `d / rate` is below one nanosecond.
+func (g *Generator) Throttled(ctx context.Context, rate int, d time.Duration) <-chan ID {
+	ch := make(chan ID)
+
+	if rate < 1 || d <= 0 || d/time.Duration(rate) <= 0 {
+		close(ch)
+		return ch
+	}
+
+	done := g.doneChan()
+
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(d / time.Duration(rate))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			id, err := g.Generate()
+			if err != nil {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case ch <- id:
+			}
+		}
+	}()
+
+	return ch
+}
+
+// Shuts the generator down: stops background work such as `Throttled`,
+// releases the machine id if a release callback was configured with
+// `WithRelease`, and makes further generation fail with `ErrorClosed`.
+// Closing the generator behind the package level functions makes
+// `Generate` panic.
+func (g *Generator) Close() error {
+	g.mutex.Lock()
+
+	if g.closed {
+		g.mutex.Unlock()
+		return &ErrorClosed
+	}
+
+	g.closed = true
+	if g.done != nil {
+		close(g.done)
+	}
+
+	release := g.release
+	g.mutex.Unlock()
+
+	if release != nil {
+		return release()
+	}
+
+	return nil
+}
+
+// Returns a channel that is closed once the generator is closed.
+func (g *Generator) doneChan() <-chan struct{} {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.done == nil {
+		g.done = make(chan struct{})
+		if g.closed {
+			close(g.done)
+		}
+	}
+
+	return g.done
+}
+
+// Verifies that the generator can currently mint strictly increasing
+// IDs, catching a stuck or backward clock. Intended for readiness
+// probes. Note that each call consumes two IDs. With a random sequence
+// start only the timestamps are compared, since the sequence may wrap.
+func (g *Generator) HealthCheck() error {
+	g.mutex.Lock()
+	randomStart := g.randomStart
+	g.mutex.Unlock()
+
+	first, err := g.Generate()
+	if err != nil {
+		return err
+	}
+
+	second, err := g.Generate()
+	if err != nil {
+		return err
+	}
+
+	if randomStart && second.EpochMillis() < first.EpochMillis() {
+		return &ErrorNotIncreasing
+	} else if !randomStart && second <= first {
+		return &ErrorNotIncreasing
+	}
+
+	return nil
+}
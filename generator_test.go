@@ -0,0 +1,680 @@
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewGenerator(t *testing.T) {
+	tests := []struct {
+		region string
+		num    int64
+		err    error
+	}{
+		{"arn", 35, nil},
+		{"lax", 0, nil},
+		{"unk", 0, &ErrorInvalidMachineId},
+		{"phx", -1, &ErrorInvalidMachineId},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_NewGenerator_%s_%d", test.region, test.num), func(t *testing.T) {
+			g, err := NewGenerator(test.region, test.num)
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			} else if err != nil {
+				return
+			}
+
+			id, err := g.Generate()
+			want, _ := newMachineId(test.region, test.num)
+
+			if err != nil {
+				t.Errorf("generate failed: %v", err)
+			} else if id.MachineId() != want {
+				t.Errorf("got machine '%d', want '%d'", id.MachineId(), want)
+			}
+		})
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	g, _ := NewGenerator("arn", 35)
+
+	for i := 0; i < 5000; i++ {
+		if err := g.HealthCheck(); err != nil {
+			t.Fatalf("health check failed: %v", err)
+		}
+	}
+}
+
+func TestHealthCheckRandomStart(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	g, _ := NewGenerator("arn", 35, WithClock(clock.Now), WithRandomSequenceStart())
+	_, _ = g.Generate()
+
+	// The next two sequences wrap around from 4095 to 0.
+	g.start = 5
+	g.sequence = bitMapMachineSequence - 1
+
+	if err := g.HealthCheck(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestHealthCheckClockBackwards(t *testing.T) {
+	g, _ := NewGenerator("arn", 35)
+	g.previous = 1 << bitsTimestamp
+
+	if err := g.HealthCheck(); !errors.Is(err, &ErrorClockBackwards) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorClockBackwards)
+	}
+}
+
+func TestRandomSequenceStart(t *testing.T) {
+	g, _ := NewGenerator("arn", 35)
+	g.SetRandomSequenceStart(true)
+
+	seen := make(map[ID]bool)
+	offsets := make(map[int64]bool)
+
+	for i := 0; i < 20000; i++ {
+		id, err := g.Generate()
+		seq := id.MachineSequence()
+
+		if err != nil {
+			t.Fatalf("generate failed: %v", err)
+		} else if seq < 0 || seq > bitMapMachineSequence {
+			t.Fatalf("sequence '%d' out of range", seq)
+		} else if seen[id] {
+			t.Fatalf("duplicate id '%v'", int64(id))
+		}
+
+		seen[id] = true
+		offsets[g.start] = true
+	}
+
+	if len(offsets) < 2 {
+		t.Errorf("got %d distinct sequence offsets, want several", len(offsets))
+	}
+}
+
+func TestSetTag(t *testing.T) {
+	tests := []struct {
+		tag int64
+		err error
+	}{
+		{0, nil},
+		{9, nil},
+		{15, nil},
+		{16, &ErrorInvalidTag},
+		{-1, &ErrorInvalidTag},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_SetTag_%d", test.tag), func(t *testing.T) {
+			g, _ := NewGenerator("arn", 35)
+
+			if err := g.SetTag(test.tag); !errors.Is(err, test.err) {
+				t.Fatalf("got '%v', want '%v'", err, test.err)
+			} else if err != nil {
+				return
+			}
+
+			seen := make(map[ID]bool)
+			perMillisecond := make(map[int64]int)
+
+			for i := 0; i < 2000; i++ {
+				id, _ := g.Generate()
+
+				parsed, err := Parse(id.String())
+				if err != nil || parsed.Tag() != test.tag {
+					t.Fatalf("got tag '%d' (%v), want '%d'", parsed.Tag(), err, test.tag)
+				} else if seen[id] {
+					t.Fatalf("duplicate id '%v'", int64(id))
+				}
+
+				seen[id] = true
+				perMillisecond[id.Time()]++
+			}
+
+			for ms, count := range perMillisecond {
+				if count > 256 {
+					t.Errorf("got %d ids in millisecond %d, want at most 256", count, ms)
+				}
+			}
+		})
+	}
+}
+
+func TestSetTagRandomStart(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	g, _ := NewGenerator("arn", 35, WithClock(clock.Now), WithRandomSequenceStart())
+	seen := make(map[ID]bool)
+
+	for i := 0; i < 100; i++ {
+		id, _ := g.TryGenerate()
+		seen[id] = true
+	}
+
+	// Mid-millisecond, with a random start most likely beyond 255.
+	if err := g.SetTag(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for ms := 0; ms < 3; ms++ {
+		for i := 0; i < 1000; i++ {
+			id, err := g.TryGenerate()
+			if errors.Is(err, &ErrorSequenceExhausted) {
+				break
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if seen[id] {
+				t.Fatalf("duplicate id '%v'", int64(id))
+			}
+
+			seen[id] = true
+		}
+
+		clock.Add(time.Millisecond)
+	}
+
+	if got, want := len(seen), 100+2*256; got != want {
+		t.Errorf("got '%d' ids, want '%d'", got, want)
+	}
+}
+
+func TestNewGeneratorFromMap(t *testing.T) {
+	g, err := NewGeneratorFromMap(map[string]string{
+		"region":  "arn",
+		"machine": "35",
+		"epoch":   "1704067200000",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	id, _ := g.Generate()
+	want, _ := newMachineId("arn", 35)
+	elapsed := time.Since(time.UnixMilli(1704067200000)).Milliseconds()
+
+	if id.MachineId() != want {
+		t.Errorf("got machine '%d', want '%d'", id.MachineId(), want)
+	} else if ms := int64(id) >> (bitsMachineID + bitsMachineSequence); ms > elapsed || ms < elapsed-1000 {
+		t.Errorf("got '%d' ms since epoch, want ~'%d'", ms, elapsed)
+	}
+}
+
+func TestNewGeneratorFromMapInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  map[string]string
+	}{
+		{"MissingRegion", map[string]string{"machine": "1"}},
+		{"MissingMachine", map[string]string{"region": "arn"}},
+		{"UnknownKey", map[string]string{"region": "arn", "machine": "1", "zone": "a"}},
+		{"UnknownRegion", map[string]string{"region": "unk", "machine": "1"}},
+		{"MachineNotInteger", map[string]string{"region": "arn", "machine": "one"}},
+		{"MachineOutOfRange", map[string]string{"region": "arn", "machine": "64"}},
+		{"EpochNotInteger", map[string]string{"region": "arn", "machine": "1", "epoch": "2020-01-01"}},
+		{"EpochInFuture", map[string]string{"region": "arn", "machine": "1", "epoch": "99999999999999"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_FromMap_%s", test.name), func(t *testing.T) {
+			g, err := NewGeneratorFromMap(test.cfg)
+
+			if !errors.Is(err, &ErrorInvalidConfig) || g != nil {
+				t.Errorf("got '%v', want '%v'", err, &ErrorInvalidConfig)
+			}
+		})
+	}
+}
+
+func TestRestoreGenerator(t *testing.T) {
+	custom := time.UnixMilli(1704067200000)
+	old, _ := NewGenerator("arn", 35, WithEpoch(custom))
+
+	var last ID
+	for i := 0; i < 100; i++ {
+		last, _ = old.Generate()
+	}
+
+	restored, err := RestoreGenerator(old.MarshalState())
+	if err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		id, err := restored.Generate()
+
+		if err != nil {
+			t.Fatalf("generate failed: %v", err)
+		} else if id <= last {
+			t.Fatalf("got '%v', want greater than '%v'", int64(id), int64(last))
+		} else if id.MachineId() != last.MachineId() {
+			t.Fatalf("got machine '%d', want '%d'", id.MachineId(), last.MachineId())
+		}
+
+		last = id
+	}
+}
+
+func TestRestoreGeneratorClockBehind(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	old, _ := NewGenerator("arn", 35, WithClock(clock.Now))
+	_, _ = old.Generate()
+
+	// The new process' clock lags behind the old one.
+	clock.Add(-time.Second)
+	restored, _ := RestoreGenerator(old.MarshalState(), WithClock(clock.Now))
+
+	if _, err := restored.Generate(); !errors.Is(err, &ErrorClockBackwards) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorClockBackwards)
+	}
+}
+
+func TestRestoreGeneratorInvalid(t *testing.T) {
+	g, _ := NewGenerator("arn", 35)
+	state := g.MarshalState()
+
+	tests := []struct {
+		name  string
+		state []byte
+	}{
+		{"Empty", nil},
+		{"Truncated", state[:24]},
+		{"WithoutLayout", state[:25]},
+		{"Version", append([]byte{0}, state[1:]...)},
+		{"MachineId", append(append([]byte{}, state[:1]...), append([]byte{0, 0, 0, 0, 0, 0, 2, 0}, state[9:]...)...)},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Restore_%s", test.name), func(t *testing.T) {
+			if g, err := RestoreGenerator(test.state); !errors.Is(err, &ErrorInvalidState) || g != nil {
+				t.Errorf("got '%v', want '%v'", err, &ErrorInvalidState)
+			}
+		})
+	}
+}
+
+func TestTryGenerate(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	g, _ := NewGenerator("arn", 35, WithClock(clock.Now))
+
+	for i := int64(0); i <= bitMapMachineSequence; i++ {
+		id, err := g.TryGenerate()
+
+		if err != nil {
+			t.Fatalf("generate failed: %v", err)
+		} else if id.MachineSequence() != i {
+			t.Fatalf("got sequence '%d', want '%d'", id.MachineSequence(), i)
+		}
+	}
+
+	if id, err := g.TryGenerate(); !errors.Is(err, &ErrorSequenceExhausted) || id != Invalid {
+		t.Fatalf("got '%v' (%v), want '%v'", int64(id), err, &ErrorSequenceExhausted)
+	}
+
+	clock.Add(time.Millisecond)
+
+	if id, err := g.TryGenerate(); err != nil || id.MachineSequence() != 0 {
+		t.Errorf("got '%v' (%v), want sequence 0", int64(id), err)
+	}
+}
+
+func TestIsSaturated(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	g, _ := NewGenerator("arn", 35, WithClock(clock.Now))
+
+	for i := int64(0); i <= bitMapMachineSequence; i++ {
+		_, _ = g.TryGenerate()
+	}
+
+	if g.IsSaturated() {
+		t.Fatalf("got saturated before exhaustion")
+	}
+
+	if _, err := g.TryGenerate(); !errors.Is(err, &ErrorSequenceExhausted) {
+		t.Fatalf("got '%v', want '%v'", err, &ErrorSequenceExhausted)
+	} else if !g.IsSaturated() {
+		t.Fatalf("got not saturated after exhaustion")
+	}
+
+	// Stays saturated for a few calls after the clock moved on.
+	clock.Add(time.Millisecond)
+
+	for i := int64(0); i < saturationWindow; i++ {
+		if !g.IsSaturated() {
+			t.Fatalf("got not saturated after %d calls", i)
+		}
+
+		_, _ = g.TryGenerate()
+	}
+
+	if g.IsSaturated() {
+		t.Errorf("got saturated after %d calls", saturationWindow)
+	}
+}
+
+func TestThrottled(t *testing.T) {
+	g, _ := NewGenerator("arn", 35)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	var previous ID
+	count := 0
+
+	// 100 IDs per 100ms, i.e. ~200 within the window.
+	for id := range g.Throttled(ctx, 100, 100*time.Millisecond) {
+		if id <= previous {
+			t.Fatalf("got '%v', want greater than '%v'", int64(id), int64(previous))
+		}
+
+		previous = id
+		count++
+	}
+
+	if count < 50 || count > 200 {
+		t.Errorf("got %d ids, want ~200", count)
+	}
+
+	if _, ok := <-g.Throttled(context.Background(), 0, time.Second); ok {
+		t.Errorf("got open channel for zero rate, want closed")
+	}
+
+	// Interval below one nanosecond.
+	if _, ok := <-g.Throttled(context.Background(), 2000, time.Microsecond); ok {
+		t.Errorf("got open channel for sub-nanosecond interval, want closed")
+	}
+}
+
+func TestNewGeneratorFunc(t *testing.T) {
+	unavailable := errors.New("coordinator unavailable")
+
+	tests := []struct {
+		name  string
+		alloc func() (int64, error)
+		err   error
+	}{
+		{"Fixed", func() (int64, error) { return 35, nil }, nil},
+		{"OutOfRange", func() (int64, error) { return 64, nil }, &ErrorInvalidMachineId},
+		{"Failing", func() (int64, error) { return 0, unavailable }, unavailable},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_NewGeneratorFunc_%s", test.name), func(t *testing.T) {
+			g, err := NewGeneratorFunc("arn", test.alloc)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got '%v', want '%v'", err, test.err)
+			} else if err != nil {
+				return
+			}
+
+			id, _ := g.Generate()
+			want, _ := newMachineId("arn", 35)
+
+			if id.MachineId() != want {
+				t.Errorf("got machine '%d', want '%d'", id.MachineId(), want)
+			}
+		})
+	}
+}
+
+func TestClose(t *testing.T) {
+	released := 0
+	g, _ := NewGeneratorFunc("arn", func() (int64, error) { return 35, nil }, WithRelease(func() error {
+		released++
+		return nil
+	}))
+
+	throttled := g.Throttled(context.Background(), 1000, time.Second)
+	<-throttled
+
+	if err := g.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	// Drains until the background goroutine stopped.
+	for range throttled {
+	}
+
+	if id, err := g.Generate(); !errors.Is(err, &ErrorClosed) || id != Invalid {
+		t.Errorf("got '%v' (%v), want '%v'", int64(id), err, &ErrorClosed)
+	} else if _, err := g.TryGenerate(); !errors.Is(err, &ErrorClosed) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorClosed)
+	} else if err := g.Close(); !errors.Is(err, &ErrorClosed) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorClosed)
+	} else if released != 1 {
+		t.Errorf("got %d releases, want 1", released)
+	}
+
+	if _, ok := <-g.Throttled(context.Background(), 1000, time.Second); ok {
+		t.Errorf("got open channel after close, want closed")
+	}
+}
+
+func TestNewStandaloneGenerator(t *testing.T) {
+	g := NewStandaloneGenerator()
+
+	var previous ID
+	for i := 0; i < 10000; i++ {
+		id, err := g.Generate()
+
+		if err != nil {
+			t.Fatalf("generate failed: %v", err)
+		} else if id <= previous {
+			t.Fatalf("got '%v' after '%v', want increasing", int64(id), int64(previous))
+		} else if id.MachineId() != g.machineId {
+			t.Fatalf("got machine '%d', want '%d'", id.MachineId(), g.machineId)
+		}
+
+		previous = id
+	}
+}
+
+func TestOnFirstGenerate(t *testing.T) {
+	g, _ := NewGenerator("arn", 35)
+
+	var calls, first atomic.Int64
+	g.OnFirstGenerate(func(id ID) {
+		calls.Add(1)
+		first.Store(int64(id))
+
+		// May use the generator.
+		if next, err := g.Generate(); err != nil || next <= id {
+			t.Errorf("got '%v' (%v), want greater than '%v'", int64(next), err, int64(id))
+		}
+	})
+
+	var wg sync.WaitGroup
+	smallest := make([]ID, 8)
+
+	for i := range smallest {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			smallest[i] = MaxID()
+			for j := 0; j < 500; j++ {
+				if id, _ := g.Generate(); id < smallest[i] {
+					smallest[i] = id
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("got '%d' calls, want '1'", calls.Load())
+	}
+
+	// The hook receives the actual first, i.e. smallest, ID.
+	if got, want := ID(first.Load()), slices.Min(smallest); got != want {
+		t.Errorf("got '%v', want '%v'", int64(got), int64(want))
+	}
+
+	// Registering afterwards has no effect.
+	g.OnFirstGenerate(func(ID) { calls.Add(1) })
+	_, _ = g.TryGenerate()
+
+	if calls.Load() != 1 {
+		t.Errorf("got '%d' calls, want '1'", calls.Load())
+	}
+}
+
+func TestGenerateStream(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	g, _ := NewGenerator("arn", 35, WithClock(clock.Now))
+	seen := make(map[ID]bool)
+
+	// Each stream gets half of the 4096 sequences of a millisecond.
+	for i := 0; i < 2048; i++ {
+		for stream := 0; stream <= 1; stream++ {
+			id, err := g.GenerateStream(stream)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if seen[id] {
+				t.Fatalf("duplicate id '%v'", int64(id))
+			} else if got := id.MachineSequence() >> 11; got != int64(stream) {
+				t.Fatalf("got stream '%d', want '%d'", got, stream)
+			}
+
+			seen[id] = true
+		}
+
+		// Shared sequence, both streams exhaust after 2048 IDs in total.
+		if i == 1023 {
+			clock.Add(time.Millisecond)
+		}
+	}
+
+	if _, err := g.TryGenerate(); !errors.Is(err, &ErrorSequenceExhausted) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorSequenceExhausted)
+	}
+
+	// Switching back to the full sequence resumes in the next millisecond.
+	clock.Add(time.Millisecond)
+	if id, err := g.Generate(); err != nil || seen[id] {
+		t.Errorf("got '%v' (%v), want unseen id", int64(id), err)
+	}
+
+	for _, stream := range []int{-1, 2} {
+		if _, err := g.GenerateStream(stream); !errors.Is(err, &ErrorInvalidSequence) {
+			t.Errorf("got '%v', want '%v'", err, &ErrorInvalidSequence)
+		}
+	}
+}
+
+func TestGenerateStreamThroughput(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	g, _ := NewGenerator("arn", 35, WithClock(clock.Now))
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for i := 0; i < 2048; i++ {
+		if _, err := g.next(false, int64(i%2)); err != nil {
+			t.Fatalf("got '%v' after %d ids, want 2048 ids per millisecond", err, i)
+		}
+	}
+
+	// Both streams together, not each.
+	for stream := int64(0); stream <= 1; stream++ {
+		if _, err := g.next(false, stream); !errors.Is(err, &ErrorSequenceExhausted) {
+			t.Errorf("got '%v', want '%v'", err, &ErrorSequenceExhausted)
+		}
+	}
+}
+
+func TestGenerateNeverPanics(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	backwards, _ := NewGenerator("arn", 35, WithClock(clock.Now))
+
+	tests := []struct {
+		name string
+		fn   func() error
+		err  error
+	}{
+		{"ClockBackwards", func() error {
+			_, _ = backwards.Generate()
+			clock.Add(-time.Second)
+			_, err := backwards.Generate()
+			return err
+		}, &ErrorClockBackwards},
+		{"ZeroValue", func() error {
+			_, err := (&Generator{}).Generate()
+			return err
+		}, &ErrorInvalidConfig},
+		{"ZeroValueTry", func() error {
+			_, err := (&Generator{}).TryGenerate()
+			return err
+		}, &ErrorInvalidConfig},
+		{"UnknownRegion", func() error {
+			return (&Generator{}).SetMachineId("nowhere", 0)
+		}, &ErrorInvalidMachineId},
+		{"InvalidIndex", func() error {
+			return (&Generator{}).SetMachineId("arn", 64)
+		}, &ErrorInvalidMachineId},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_NeverPanics_%s", test.name), func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("got panic '%v', want '%v'", r, test.err)
+				}
+			}()
+
+			if err := test.fn(); !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			}
+		})
+	}
+}
+
+func TestGeneratorSetMachineId(t *testing.T) {
+	g, _ := NewGenerator("arn", 1, WithMachineBits(2))
+
+	if err := g.SetMachineId("iad", 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	id, _ := g.Generate()
+	if _, machineId, _ := g.Decompose(id); machineId != 2<<2|3 {
+		t.Errorf("got '%d', want '%d'", machineId, 2<<2|3)
+	}
+
+	// Does not fit 2 bits, keeps the previous machine id.
+	if err := g.SetMachineId("arn", 4); !errors.Is(err, &ErrorInvalidMachineId) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalidMachineId)
+	}
+
+	id, _ = g.Generate()
+	if _, machineId, _ := g.Decompose(id); machineId != 2<<2|3 {
+		t.Errorf("got '%d', want '%d'", machineId, 2<<2|3)
+	}
+}
+
+// 3.4 ns/op, a negligible share of Generate, which is bound by the
+// sequence space at ~244 ns/op. Caching the millisecond bounds and
+// comparing readings against them measured slower, 4.3 ns/op.
+func BenchmarkEpochMillis(b *testing.B) {
+	now := time.Now()
+	g, _ := NewGenerator("arn", 35, WithClock(func() time.Time { return now }))
+
+	for i := 0; i < b.N; i++ {
+		_ = g.now().Sub(g.epoch).Milliseconds()
+	}
+}
@@ -0,0 +1,154 @@
+package snowflake
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+)
+
+// Extended 128 bit snowflake for more machine and sequence headroom.
+// The following format is used:
+// +-----------------------------------------------------------------------+
+// | 48 bit Timestamp | 16 bit Machine ID | 24 bit Sequence | 40 bit Random |
+// +-----------------------------------------------------------------------+
+//
+// Hi holds timestamp and machine id, Lo sequence and random bits, hence
+// comparing Hi first and Lo second sorts by generation order.
+type ID128 struct {
+	Hi, Lo uint64
+}
+
+// Bit widths of the 128 bit layout. 48 bits cover ~8900 years from Epoch.
+const (
+	bits128Timestamp = 48
+	bits128MachineID = 16
+	bits128Sequence  = 24
+	bits128Random    = 40
+)
+
+// Sequence state for `Generate128`, independent of the 64 bit generator.
+var generator128 struct {
+	mutex    sync.Mutex
+	previous int64
+	sequence uint64
+}
+
+// Generates a unique 128 bit snowflake with the machine id and epoch of
+// the default generator, see `SetMachineId`. Waits for the next
+// millisecond once 2^24 IDs were minted. A clock moving backwards is
+// absorbed by continuing the sequence of the latest millisecond.
+func Generate128() ID128 {
+	now, machineId := read128()
+
+	g := &generator128
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if now < g.previous {
+		now = g.previous
+	}
+
+	if now == g.previous {
+		g.sequence++
+
+		// Reached max sequence number, wait for the next millisecond.
+		if g.sequence == 1<<bits128Sequence {
+			for now <= g.previous {
+				now, _ = read128()
+			}
+
+			g.sequence = 0
+		}
+	} else {
+		g.sequence = 0
+	}
+
+	g.previous = now
+	random := rand.Uint64() & (1<<bits128Random - 1)
+
+	return ID128{
+		Hi: uint64(now)<<bits128MachineID | machineId,
+		Lo: g.sequence<<bits128Random | random,
+	}
+}
+
+// Returns milliseconds since epoch and machine id of the default generator.
+func read128() (int64, uint64) {
+	defaultGenerator.mutex.Lock()
+	defer defaultGenerator.mutex.Unlock()
+
+	return defaultGenerator.now().Sub(defaultGenerator.epoch).Milliseconds(), uint64(defaultGenerator.machineId)
+}
+
+// Extracts timestamp in Unix milliseconds from a 128 bit snowflake.
+func (id ID128) Time() int64 {
+	return int64(id.Hi>>bits128MachineID) + Epoch
+}
+
+// Extracts machine id from a 128 bit snowflake.
+func (id ID128) MachineId() int64 {
+	return int64(id.Hi & (1<<bits128MachineID - 1))
+}
+
+// Extracts sequence number from a 128 bit snowflake.
+func (id ID128) MachineSequence() int64 {
+	return int64(id.Lo >> bits128Random)
+}
+
+// Returns the base 54 encoded representation of a 128 bit snowflake,
+// at most 23 chars (log(54,2^128)<23).
+func (id ID128) String() string {
+	alphabet := active.Load().alphabet
+
+	var b [23]byte
+	i := len(b)
+	hi, lo := id.Hi, id.Lo
+
+	for {
+		var r uint64
+		hi, r = bits.Div64(0, hi, 54)
+		lo, r = bits.Div64(r, lo, 54)
+
+		i--
+		b[i] = alphabet[r]
+
+		if hi == 0 && lo == 0 {
+			return string(b[i:])
+		}
+	}
+}
+
+// Converts a base 54 encoded string into a 128 bit snowflake.
+func ParseID128(input string) (ID128, error) {
+	var id ID128
+
+	if len(input) == 0 {
+		return id, &ErrorEmptyInput
+	} else if len(input) > 23 {
+		return id, &ErrorInvalid
+	}
+
+	decodeMap := &active.Load().decodeMap
+
+	for i := 0; i < len(input); i++ {
+		d := decodeMap[input[i]]
+		if d == 0xFF {
+			return ID128{}, &ErrorInvalidByte
+		}
+
+		// id = id*54 + d, rejecting anything beyond 128 bits.
+		carry, lo := bits.Mul64(id.Lo, 54)
+		overflow, hi := bits.Mul64(id.Hi, 54)
+		hi, c1 := bits.Add64(hi, carry, 0)
+		lo, c2 := bits.Add64(lo, uint64(d), 0)
+		hi, c3 := bits.Add64(hi, 0, c2)
+
+		if overflow != 0 || c1 != 0 || c3 != 0 {
+			return ID128{}, &ErrorInvalid
+		}
+
+		id = ID128{Hi: hi, Lo: lo}
+	}
+
+	return id, nil
+}
@@ -0,0 +1,107 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGenerate128(t *testing.T) {
+	SetMachineId("arn", 35)
+	start := time.Now().UnixMilli()
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[ID128]bool)
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var previous ID128
+			for j := 0; j < 10000; j++ {
+				id := Generate128()
+
+				// Strictly increasing within a goroutine.
+				if id.Hi < previous.Hi || id.Hi == previous.Hi && id.Lo <= previous.Lo {
+					t.Errorf("got '%v' after '%v', want increasing", id, previous)
+					return
+				}
+
+				previous = id
+
+				mutex.Lock()
+				if seen[id] {
+					t.Errorf("duplicate id '%v'", id)
+				}
+
+				seen[id] = true
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	id := Generate128()
+	if id.MachineId() != 5<<6|35 {
+		t.Errorf("got machine '%d', want '%d'", id.MachineId(), 5<<6|35)
+	} else if id.Time() < start || id.Time() > time.Now().UnixMilli() {
+		t.Errorf("got time '%d', want between '%d' and now", id.Time(), start)
+	}
+}
+
+func TestID128Encoding(t *testing.T) {
+	tests := []ID128{
+		{0, 0},
+		{0, 53},
+		{0, 54},
+		{0, math.MaxUint64},
+		{1, 0},
+		{0x00000180A1B2C3D4, 0x0000ABCDEF012345},
+		{math.MaxUint64, math.MaxUint64},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ID128_%x_%x", test.Hi, test.Lo), func(t *testing.T) {
+			encoded := test.String()
+			id, err := ParseID128(encoded)
+
+			if err != nil || id != test {
+				t.Errorf("got '%v' (%v), want '%v'", id, err, test)
+			} else if len(encoded) > 23 {
+				t.Errorf("got length '%d', want at most 23", len(encoded))
+			}
+		})
+	}
+
+	if got := (ID128{}).String(); got != string(DefaultAlphabet[0]) {
+		t.Errorf("got '%s', want '%s'", got, string(DefaultAlphabet[0]))
+	}
+}
+
+func TestParseID128Invalid(t *testing.T) {
+	max := ID128{math.MaxUint64, math.MaxUint64}.String()
+
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"", &ErrorEmptyInput},
+		{"8uy!", &ErrorInvalidByte},
+		{max + "8", &ErrorInvalid},
+		{string(DefaultAlphabet[53]) + max[1:], &ErrorInvalid}, // beyond 2^128
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseID128_%s", test.input), func(t *testing.T) {
+			if _, err := ParseID128(test.input); !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			}
+		})
+	}
+}
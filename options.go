@@ -0,0 +1,112 @@
+package snowflake
+
+import "time"
+
+// Option configures a generator, see `NewGenerator`.
+type Option func(*Generator) error
+
+// Uses a custom epoch instead of `Epoch`. IDs minted with a custom
+// epoch must be decoded with that same epoch, since `ID.Time` assumes
+// the default one.
+func WithEpoch(t time.Time) Option {
+	return func(g *Generator) error {
+		if t.After(time.Now()) {
+			return &ErrorInvalidEpoch
+		}
+
+		g.epoch = monotonicEpoch(t.UnixMilli())
+		return nil
+	}
+}
+
+// Uses a custom clock instead of `time.Now`, e.g. a fake clock for tests.
+// Note that only readings with monotonic information protect against
+// wall clock adjustments.
+func WithClock(now func() time.Time) Option {
+	return func(g *Generator) error {
+		g.now = now
+		return nil
+	}
+}
+
+// Starts the sequence of each new millisecond at a random offset,
+// see `Generator.SetRandomSequenceStart`.
+func WithRandomSequenceStart() Option {
+	return func(g *Generator) error {
+		g.SetRandomSequenceStart(true)
+		return nil
+	}
+}
+
+// Reserves the low sequence bits for a constant tag, see `Generator.SetTag`.
+func WithTag(tag int64) Option {
+	return func(g *Generator) error {
+		return g.SetTag(tag)
+	}
+}
+
+// Counts the IDs minted per millisecond and returns `ErrorSequenceExhausted`
+// instead of a duplicate, should the sequence ever wrap around to an
+// already used value. A safety net against logic or layout changes.
+func WithSequenceGuard() Option {
+	return func(g *Generator) error {
+		g.guard = true
+		return nil
+	}
+}
+
+// Calls release once the generator is closed, e.g. to hand a machine
+// index back to a coordination system, see `NewGeneratorFunc`.
+func WithRelease(release func() error) Option {
+	return func(g *Generator) error {
+		g.release = release
+		return nil
+	}
+}
+
+// Reduces the machine index to the given number of bits, 6 by default,
+// and extends the sequence by the freed bits, e.g. 2 machine index bits
+// allow 4 machines per continent with 65536 IDs per millisecond each.
+// ATTENTION: The ID accessors assume the default layout, use
+// `Generator.Decompose` to extract the fields of such IDs instead.
+func WithMachineBits(bits int64) Option {
+	return func(g *Generator) error {
+		indexBits := bitsMachineID - 3 - g.freedBits
+		continent := g.machineId >> indexBits
+		index := g.machineId & (1<<indexBits - 1)
+
+		if bits < 0 || bits > bitsMachineID-3 || index >= 1<<bits {
+			return &ErrorInvalidMachineId
+		}
+
+		g.machineId = continent<<bits | index
+		g.freedBits = bitsMachineID - 3 - bits
+		return nil
+	}
+}
+
+// Guards the generator with a spinlock instead of a `sync.Mutex`, which
+// avoids parking goroutines under high contention, at the cost of
+// burning CPU while waiting. Only slightly faster without contention,
+// whether it pays off with contention depends on the hardware, hence
+// opt-in. Run `BenchmarkGenerateSpinLock` on the target before enabling it.
+func WithSpinLock() Option {
+	return func(g *Generator) error {
+		g.mutex.spin = true
+		return nil
+	}
+}
+
+// Keeps generating when the clock is frozen or moves backwards, e.g. in
+// sandboxes: the generator continues from its latest timestamp, and once
+// the sequence of a millisecond is exhausted, advances the timestamp by
+// one instead of waiting for the clock. Neither `ErrorClockBackwards`
+// nor `ErrorSequenceExhausted` are returned.
+// ATTENTION: Timestamps then diverge from wall clock time, running ahead
+// of it, until the clock catches up. IDs remain unique and increasing.
+func WithMonotonicFallback() Option {
+	return func(g *Generator) error {
+		g.fallback = true
+		return nil
+	}
+}
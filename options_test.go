@@ -0,0 +1,301 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Manually advanced clock for deterministic tests.
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestOptions(t *testing.T) {
+	custom := time.UnixMilli(1704067200000)
+	clock := newFakeClock(custom.Add(1000 * time.Millisecond))
+
+	g, err := NewGenerator("arn", 35,
+		WithEpoch(custom),
+		WithClock(clock.Now),
+		WithTag(3),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, _ := newMachineId("arn", 35)
+
+	for i, elapsed := range []int64{1000, 1000, 1005} {
+		if i == 2 {
+			clock.Add(5 * time.Millisecond)
+		}
+
+		id, err := g.Generate()
+		timestamp, machineId, sequence := id.Decompose()
+
+		if err != nil {
+			t.Fatalf("generate failed: %v", err)
+		} else if timestamp != elapsed || machineId != want {
+			t.Errorf("got '%d/%d', want '%d/%d'", timestamp, machineId, elapsed, want)
+		} else if id.Tag() != 3 || sequence>>bitsTag != int64(i%2) {
+			t.Errorf("got tag '%d' and sequence '%d'", id.Tag(), sequence)
+		}
+	}
+}
+
+func TestOptionsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+		err  error
+	}{
+		{"FutureEpoch", WithEpoch(time.Now().Add(time.Hour)), &ErrorInvalidEpoch},
+		{"Tag", WithTag(16), &ErrorInvalidTag},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Option_%s", test.name), func(t *testing.T) {
+			g, err := NewGenerator("arn", 35, WithRandomSequenceStart(), test.opt)
+
+			if !errors.Is(err, test.err) || g != nil {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			}
+		})
+	}
+}
+
+func TestWithSequenceGuard(t *testing.T) {
+	for _, guard := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Test_SequenceGuard_%v", guard), func(t *testing.T) {
+			clock := newFakeClock(time.Now())
+			opts := []Option{WithClock(clock.Now)}
+			if guard {
+				opts = append(opts, WithSequenceGuard())
+			}
+
+			g, _ := NewGenerator("arn", 35, opts...)
+			first, _ := g.Generate()
+
+			// Simulate a bug bypassing the exhaustion check.
+			g.start = -1
+
+			var id ID
+			var err error
+
+			for i := int64(0); i <= bitMapMachineSequence && err == nil; i++ {
+				id, err = g.Generate()
+			}
+
+			if guard && !errors.Is(err, &ErrorSequenceExhausted) {
+				t.Errorf("got '%v' (%v), want '%v'", int64(id), err, &ErrorSequenceExhausted)
+			} else if !guard && id != first {
+				t.Errorf("got '%v', want wrapped duplicate '%v'", int64(id), int64(first))
+			}
+		})
+	}
+}
+
+func TestWithMachineBits(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	g, err := NewGenerator("arn", 3, WithClock(clock.Now), WithMachineBits(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first, _ := g.Generate()
+	seen := map[ID]bool{first: true}
+
+	// 16 sequence bits within a single millisecond.
+	for i := int64(1); i < 1<<16; i++ {
+		id, err := g.TryGenerate()
+		timestamp, machineId, sequence := g.Decompose(id)
+
+		if err != nil {
+			t.Fatalf("generate %d failed: %v", i, err)
+		} else if seen[id] {
+			t.Fatalf("duplicate id '%v'", int64(id))
+		} else if timestamp != first.EpochMillis() || machineId != 5<<2|3 || sequence != i {
+			t.Fatalf("got '%d/%d/%d', want '%d/%d/%d'", timestamp, machineId, sequence, first.EpochMillis(), 5<<2|3, i)
+		}
+
+		seen[id] = true
+	}
+
+	if _, err := g.TryGenerate(); !errors.Is(err, &ErrorSequenceExhausted) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorSequenceExhausted)
+	}
+
+	// Survives a handoff.
+	clock.Add(time.Millisecond)
+	restored, _ := RestoreGenerator(g.MarshalState(), WithClock(clock.Now))
+	if id, _ := restored.Generate(); id <= first {
+		t.Errorf("got '%v', want greater than '%v'", int64(id), int64(first))
+	} else if _, machineId, _ := restored.Decompose(id); machineId != 5<<2|3 {
+		t.Errorf("got machine '%d', want '%d'", machineId, 5<<2|3)
+	}
+}
+
+func TestWithMachineBitsInvalid(t *testing.T) {
+	tests := []struct {
+		index int64
+		bits  int64
+	}{
+		{4, 2},
+		{1, 0},
+		{0, 7},
+		{0, -1},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_MachineBits_%d_%d", test.index, test.bits), func(t *testing.T) {
+			if _, err := NewGenerator("arn", test.index, WithMachineBits(test.bits)); !errors.Is(err, &ErrorInvalidMachineId) {
+				t.Errorf("got '%v', want '%v'", err, &ErrorInvalidMachineId)
+			}
+		})
+	}
+}
+
+func TestTimeUntilOverflowEpoch(t *testing.T) {
+	// Epochs have millisecond precision.
+	clock := newFakeClock(time.Now().Truncate(time.Millisecond))
+	g, _ := NewGenerator("arn", 0, WithClock(clock.Now), WithEpoch(clock.Now().Add(-time.Hour)))
+	want := time.Duration(1<<42)*time.Millisecond - time.Hour
+
+	if got := g.TimeUntilOverflow(); got != want {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+
+	// Past the ceiling.
+	clock.Add(time.Duration(1<<42) * time.Millisecond)
+	if got := g.TimeUntilOverflow(); got != -time.Hour {
+		t.Errorf("got '%v', want '%v'", got, -time.Hour)
+	}
+}
+
+func TestWithSpinLock(t *testing.T) {
+	g, err := NewGenerator("arn", 35, WithSpinLock())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]ID, 8)
+
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 2000; j++ {
+				id, err := g.Generate()
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+
+				results[i] = append(results[i], id)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	seen := make(map[ID]bool)
+	for _, ids := range results {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("duplicate id '%v'", int64(id))
+			}
+
+			seen[id] = true
+		}
+	}
+}
+
+// Advances 1 µs per reading, i.e. 1000 IDs per millisecond, such that
+// the sequence never exhausts and only the lock overhead is measured.
+// Contended numbers depend on the core count, hence only procs=1 below.
+func benchmarkLock(b *testing.B, opts ...Option) {
+	var ticks atomic.Int64
+	start := time.Now()
+	clock := func() time.Time {
+		return start.Add(time.Duration(ticks.Add(1)) * time.Microsecond)
+	}
+
+	for _, procs := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("procs=%d", procs), func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+			g, _ := NewGenerator("arn", 35, append(opts, WithClock(clock))...)
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, _ = g.Generate()
+				}
+			})
+		})
+	}
+}
+
+// procs=1: 58.23 ns/op
+func BenchmarkGenerateMutex(b *testing.B) {
+	benchmarkLock(b)
+}
+
+// procs=1: 55.53 ns/op
+func BenchmarkGenerateSpinLock(b *testing.B) {
+	benchmarkLock(b, WithSpinLock())
+}
+
+func TestWithMonotonicFallback(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	g, _ := NewGenerator("arn", 35, WithClock(clock.Now), WithMonotonicFallback())
+
+	first, _ := g.Generate()
+	previous := first
+
+	// Frozen clock, 3 milliseconds worth of IDs.
+	for i := 0; i < 3*4096; i++ {
+		id, err := g.TryGenerate()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if id <= previous {
+			t.Fatalf("got '%v' after '%v', want increasing", int64(id), int64(previous))
+		}
+
+		previous = id
+	}
+
+	// Ran ahead of the clock.
+	if got := previous.EpochMillis() - first.EpochMillis(); got != 3 {
+		t.Errorf("got '%d' ms ahead, want '3'", got)
+	}
+
+	// Clock moving backwards.
+	clock.Add(-time.Second)
+	if id, err := g.Generate(); err != nil || id <= previous {
+		t.Errorf("got '%v' (%v), want greater than '%v'", int64(id), err, int64(previous))
+	}
+}
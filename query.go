@@ -0,0 +1,24 @@
+package snowflake
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Reads a base encoded snowflake from a URL query parameter, ignoring
+// surrounding whitespace. Returns `ErrorMissingParameter` if the key is
+// absent or blank, otherwise the parse error, both naming the key.
+func FromQuery(values url.Values, key string) (ID, error) {
+	input := strings.TrimSpace(values.Get(key))
+	if input == "" {
+		return Invalid, fmt.Errorf("%w: %q", &ErrorMissingParameter, key)
+	}
+
+	id, err := Parse(input)
+	if err != nil {
+		return Invalid, fmt.Errorf("%w: query parameter %q", err, key)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,35 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func TestFromQuery(t *testing.T) {
+	values, _ := url.ParseQuery("id=8uyZY2sj3re&padded=%206vF%20&blank=%20&bad=8uy!&long=8888888888888888888")
+
+	tests := []struct {
+		key    string
+		verify ID
+		err    error
+	}{
+		{"id", ID(305023354946072576), nil},
+		{"padded", ID(123123), nil},
+		{"missing", Invalid, &ErrorMissingParameter},
+		{"blank", Invalid, &ErrorMissingParameter},
+		{"bad", Invalid, &ErrorInvalidByte},
+		{"long", Invalid, &ErrorInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_FromQuery_%s", test.key), func(t *testing.T) {
+			id, err := FromQuery(values, test.key)
+
+			if id != test.verify || !errors.Is(err, test.err) {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id), err, int64(test.verify), test.err)
+			}
+		})
+	}
+}
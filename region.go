@@ -1,5 +1,7 @@
 package snowflake
 
+import "sync"
+
 // Continents, from largest to smallest.
 // Fly.io regions extracted from https://fly.io/docs/reference/regions/
 // TODO: Support more region codes.
@@ -20,6 +22,59 @@ var continents = [][]string{
 	{"syd"},
 }
 
+// Empty continents lending their machine slots, per borrowing continent.
+var lenders = map[int64][]int64{}
+var lendersMutex sync.RWMutex
+
+// Lends the 64 machine slots of an empty continent to another continent.
+// The borrowing continent accepts machine indices beyond 63, where
+// indices 64 to 127 map to the first lender, 128 to 191 to the second,
+// and so on. Uniqueness is preserved, since no region encodes the
+// lending continent. ATTENTION: This changes how machine bits decode,
+// IDs from borrowed slots carry the continent code of the lender.
+// Should be called on startup, before any generator is created.
+func BorrowFrom(empty, target int64) error {
+	lendersMutex.Lock()
+	defer lendersMutex.Unlock()
+
+	if empty < 0 || empty > 0b111 || target < 0 || target >= int64(len(continents)) {
+		return &ErrorInvalidBorrow
+	} else if empty < int64(len(continents)) && len(continents[empty]) > 0 {
+		return &ErrorInvalidBorrow
+	} else if len(continents[target]) == 0 {
+		return &ErrorInvalidBorrow
+	}
+
+	for _, l := range lenders {
+		for i := range l {
+			if l[i] == empty {
+				return &ErrorInvalidBorrow
+			}
+		}
+	}
+
+	lenders[target] = append(lenders[target], empty)
+	return nil
+}
+
+// Resolves a machine index beyond a continent's 64 slots to a
+// borrowed continent and index, see `BorrowFrom`.
+func resolveBorrowed(continent, index, slots int64) (int64, int64) {
+	if index < slots {
+		return continent, index
+	}
+
+	lendersMutex.RLock()
+	defer lendersMutex.RUnlock()
+
+	k := index/slots - 1
+	if k >= int64(len(lenders[continent])) {
+		return continent, index
+	}
+
+	return lenders[continent][k], index % slots
+}
+
 func getContinentCode(region string) int64 {
 	for i := 0; i < len(continents); i++ {
 		for j := range continents[i] {
@@ -31,3 +86,102 @@ func getContinentCode(region string) int64 {
 
 	return -1
 }
+
+// Returns a representative region for a continent code, i.e. the first
+// region registered for it. This is a best-effort display helper for
+// decoded IDs, not a true inverse, since the region itself is not encoded.
+func RegionFor(continent int64) (string, bool) {
+	if continent < 0 || continent >= int64(len(continents)) || len(continents[continent]) == 0 {
+		return "", false
+	}
+
+	return continents[continent][0], true
+}
+
+// Returns the lowest machine index of a continent that is not in use,
+// as a building block for lightweight coordination of ephemeral workers.
+// Indices outside of the valid range are ignored.
+func NextFreeIndex(continent int64, inUse []int64) (int64, error) {
+	if continent < 0 || continent >= int64(len(continents)) {
+		return -1, &ErrorInvalidMachineId
+	}
+
+	var taken [1 << (bitsMachineID - 3)]bool
+	for _, index := range inUse {
+		if index >= 0 && index < int64(len(taken)) {
+			taken[index] = true
+		}
+	}
+
+	for i := range taken {
+		if !taken[i] {
+			return int64(i), nil
+		}
+	}
+
+	return -1, &ErrorNoFreeMachineId
+}
+
+// Returns a region of the snowflake's continent, picked deterministically
+// by machine index modulo the number of regions of that continent.
+// ATTENTION: This is a heuristic display aid, NOT authoritative, since
+// the machine index is unrelated to the region the ID was minted in.
+func (id ID) LikelyRegion() (string, bool) {
+	continent := id.MachineId() >> (bitsMachineID - 3)
+	index := id.MachineId() & (1<<(bitsMachineID-3) - 1)
+
+	if continent >= int64(len(continents)) || len(continents[continent]) == 0 {
+		return "", false
+	}
+
+	return continents[continent][index%int64(len(continents[continent]))], true
+}
+
+// Returns `count` distinct machine indices 0 to count-1 for bootstrapping
+// a fleet in a region. Errors if the region is unknown or the count
+// exceeds the 64 machines per continent, plus any borrowed slots.
+func AssignMachineIds(region string, count int64) ([]int64, error) {
+	if getContinentCode(region) < 0 || count < 0 {
+		return nil, &ErrorInvalidMachineId
+	} else if count == 0 {
+		return []int64{}, nil
+	} else if _, err := newMachineId(region, count-1); err != nil {
+		return nil, err
+	}
+
+	indices := make([]int64, count)
+	for i := range indices {
+		indices[i] = int64(i)
+	}
+
+	return indices, nil
+}
+
+// Verifies the snowflake like `Validate` and additionally that its
+// continent has registered regions or lends its slots, see `BorrowFrom`.
+// An empty continent such as Antarctica usually signals corruption or an
+// ID minted against a decommissioned region table. Opt-in, since
+// historical IDs may legitimately reference removed regions.
+func (id ID) ValidateRegion() error {
+	if err := id.Validate(); err != nil {
+		return err
+	}
+
+	continent := id.MachineId() >> (bitsMachineID - 3)
+	if continent < int64(len(continents)) && len(continents[continent]) > 0 {
+		return nil
+	}
+
+	lendersMutex.RLock()
+	defer lendersMutex.RUnlock()
+
+	for _, l := range lenders {
+		for i := range l {
+			if l[i] == continent {
+				return nil
+			}
+		}
+	}
+
+	return &ErrorUnknownContinent
+}
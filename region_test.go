@@ -0,0 +1,226 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRegionFor(t *testing.T) {
+	tests := []struct {
+		continent int64
+		region    string
+		ok        bool
+	}{
+		{0, "bom", true},
+		{2, "atl", true},
+		{4, "", false}, // Antarctica
+		{5, "ams", true},
+		{7, "", false},
+		{-1, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_RegionFor_%d", test.continent), func(t *testing.T) {
+			region, ok := RegionFor(test.continent)
+
+			if region != test.region || ok != test.ok {
+				t.Errorf("got '%s' (%v), want '%s' (%v)", region, ok, test.region, test.ok)
+			} else if ok && getContinentCode(region) != test.continent {
+				t.Errorf("got continent '%d', want '%d'", getContinentCode(region), test.continent)
+			}
+		})
+	}
+}
+
+func TestNextFreeIndex(t *testing.T) {
+	full := make([]int64, 64)
+	for i := range full {
+		full[i] = int64(i)
+	}
+
+	tests := []struct {
+		name      string
+		continent int64
+		inUse     []int64
+		verify    int64
+		err       error
+	}{
+		{"Empty", 5, nil, 0, nil},
+		{"Partial", 5, []int64{0, 1, 2, 4, 99, -1}, 3, nil},
+		{"LastFree", 2, full[:63], 63, nil},
+		{"Full", 2, full, -1, &ErrorNoFreeMachineId},
+		{"InvalidContinent", 8, nil, -1, &ErrorInvalidMachineId},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_NextFreeIndex_%s", test.name), func(t *testing.T) {
+			index, err := NextFreeIndex(test.continent, test.inUse)
+
+			if index != test.verify || !errors.Is(err, test.err) {
+				t.Errorf("got '%d' (%v), want '%d' (%v)", index, err, test.verify, test.err)
+			}
+		})
+	}
+}
+
+func TestBorrowFrom(t *testing.T) {
+	t.Cleanup(func() { lenders = map[int64][]int64{} })
+
+	// North America has no slots beyond 63 yet.
+	if _, err := newMachineId("atl", 64); !errors.Is(err, &ErrorInvalidMachineId) {
+		t.Fatalf("got '%v', want '%v'", err, &ErrorInvalidMachineId)
+	}
+
+	tests := []struct {
+		empty  int64
+		target int64
+		err    error
+	}{
+		{4, 2, nil},                  // Antarctica to North America
+		{4, 5, &ErrorInvalidBorrow},  // already lent
+		{7, 2, nil},                  // unused continent code
+		{5, 2, &ErrorInvalidBorrow},  // Europe is not empty
+		{6, 4, &ErrorInvalidBorrow},  // Antarctica has no regions
+		{8, 2, &ErrorInvalidBorrow},  // out of range
+		{-1, 2, &ErrorInvalidBorrow}, // out of range
+	}
+
+	for _, test := range tests {
+		if err := BorrowFrom(test.empty, test.target); !errors.Is(err, test.err) {
+			t.Errorf("BorrowFrom(%d, %d): got '%v', want '%v'", test.empty, test.target, err, test.err)
+		}
+	}
+
+	// Every machine id across all regions has to be unique.
+	seen := make(map[int64]string)
+	for i := range continents {
+		for _, region := range continents[i] {
+			for index := int64(0); index < 64*3; index++ {
+				machineId, err := newMachineId(region, index)
+
+				if i == 2 && err != nil {
+					t.Fatalf("region '%s' index %d: %v", region, index, err)
+				} else if err != nil {
+					continue
+				}
+
+				// Regions share their continent's machine ids.
+				key := fmt.Sprintf("%d/%d", i, index)
+				if other, ok := seen[machineId]; ok && other != key {
+					t.Fatalf("machine id %d used by '%s' and '%s'", machineId, other, key)
+				}
+
+				seen[machineId] = key
+			}
+		}
+	}
+
+	if got, _ := newMachineId("atl", 70); got>>6 != 4 || got&0b111111 != 6 {
+		t.Errorf("got continent '%d' index '%d', want '4' index '6'", got>>6, got&0b111111)
+	}
+
+	if _, err := newMachineId("atl", 64*3); !errors.Is(err, &ErrorInvalidMachineId) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalidMachineId)
+	}
+}
+
+func TestLikelyRegion(t *testing.T) {
+	tests := []struct {
+		region string
+		index  int64
+		verify string
+		ok     bool
+	}{
+		{"arn", 35, "fra", true}, // Europe, 35 % 8 regions
+		{"ams", 0, "ams", true},
+		{"syd", 17, "syd", true},
+		{"atl", 14, "atl", true},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_LikelyRegion_%s_%d", test.region, test.index), func(t *testing.T) {
+			id, _ := ID(305023354946072576).WithMachine(test.region, test.index)
+			region, ok := id.LikelyRegion()
+
+			if region != test.verify || ok != test.ok {
+				t.Errorf("got '%s' (%v), want '%s' (%v)", region, ok, test.verify, test.ok)
+			}
+		})
+	}
+
+	// Antarctica has no regions.
+	antarctica := IDFromEpochMillis(1, 4<<(bitsMachineID-3), 0)
+	if region, ok := antarctica.LikelyRegion(); ok {
+		t.Errorf("got '%s', want none", region)
+	}
+}
+
+func TestAssignMachineIds(t *testing.T) {
+	tests := []struct {
+		region string
+		count  int64
+		err    error
+	}{
+		{"arn", 0, nil},
+		{"arn", 10, nil},
+		{"lax", 64, nil},
+		{"lax", 65, &ErrorInvalidMachineId},
+		{"unk", 1, &ErrorInvalidMachineId},
+		{"arn", -1, &ErrorInvalidMachineId},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_AssignMachineIds_%s_%d", test.region, test.count), func(t *testing.T) {
+			indices, err := AssignMachineIds(test.region, test.count)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got '%v', want '%v'", err, test.err)
+			} else if err != nil {
+				return
+			} else if int64(len(indices)) != test.count {
+				t.Fatalf("got %d indices, want %d", len(indices), test.count)
+			}
+
+			for i, index := range indices {
+				if index != int64(i) {
+					t.Errorf("got index '%d' at %d", index, i)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRegion(t *testing.T) {
+	t.Cleanup(func() { lenders = map[int64][]int64{} })
+
+	europe, _ := ID(305023354946072576).WithMachine("arn", 35)
+	antarctica := IDFromEpochMillis(1, 4<<(bitsMachineID-3)|3, 0)
+
+	tests := []struct {
+		name string
+		id   ID
+		err  error
+	}{
+		{"Europe", europe, nil},
+		{"Antarctica", antarctica, &ErrorUnknownContinent},
+		{"Unused", IDFromEpochMillis(1, 7<<(bitsMachineID-3), 0), &ErrorUnknownContinent},
+		{"Negative", Invalid, &ErrorReservedBitSet},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ValidateRegion_%s", test.name), func(t *testing.T) {
+			if err := test.id.ValidateRegion(); !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			} else if err := test.id.Validate(); test.id >= 0 && err != nil {
+				t.Errorf("got '%v' from Validate, want no continent check", err)
+			}
+		})
+	}
+
+	// Lending Antarctica makes its continent code legitimate.
+	_ = BorrowFrom(4, 2)
+	if err := antarctica.ValidateRegion(); err != nil {
+		t.Errorf("got '%v' for borrowed slot, want nil", err)
+	}
+}
@@ -0,0 +1,97 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Machine ids held by live generators of `NewRegisteredGenerator`.
+var registered = map[int64]bool{}
+var registeredMutex sync.Mutex
+
+// Creates a generator like `NewGenerator`, but registers its machine id
+// process wide and returns `ErrorMachineIdInUse` if a live registered
+// generator already holds it, catching duplicate configs within a single
+// binary. `Close` releases the registration, `Generator.SetMachineId`
+// is rejected. Generators created otherwise, including the default one,
+// are not taken into account.
+func NewRegisteredGenerator(region string, index int64) (*Generator, error) {
+	machineId, err := newMachineId(region, index)
+	if err != nil {
+		return nil, err
+	}
+
+	registeredMutex.Lock()
+	defer registeredMutex.Unlock()
+
+	if registered[machineId] {
+		return nil, fmt.Errorf("%w: machine id %d", &ErrorMachineIdInUse, machineId)
+	}
+
+	g, err := NewGenerator(region, index, WithRelease(func() error {
+		registeredMutex.Lock()
+		defer registeredMutex.Unlock()
+
+		delete(registered, machineId)
+		return nil
+	}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	g.registered = true
+	registered[machineId] = true
+	return g, nil
+}
+
+// Validates a cluster's machine assignment before deploying it, keyed by
+// "<region>/<host>" with the machine index as value, e.g. "arn/web-1": 3.
+// Returns all problems at once, joined, or nil: `ErrorInvalidConfig` for
+// keys without region, `ErrorInvalidMachineId` for unknown regions or
+// out of range indices and `ErrorMachineIdInUse` listing all hosts of a
+// machine id, which also catches regions sharing a continent.
+func ValidateAssignments(assignments map[string]int64) error {
+	keys := make([]string, 0, len(assignments))
+	for key := range assignments {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	var errs []error
+	hosts := make(map[int64][]string)
+	var machineIds []int64
+
+	for _, key := range keys {
+		region, _, ok := strings.Cut(key, "/")
+		if !ok || region == "" {
+			errs = append(errs, fmt.Errorf("%w: %q lacks a region", &ErrorInvalidConfig, key))
+			continue
+		}
+
+		machineId, err := newMachineId(region, assignments[key])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %q with index %d", err, key, assignments[key]))
+			continue
+		}
+
+		if hosts[machineId] == nil {
+			machineIds = append(machineIds, machineId)
+		}
+
+		hosts[machineId] = append(hosts[machineId], key)
+	}
+
+	for _, machineId := range machineIds {
+		if len(hosts[machineId]) > 1 {
+			errs = append(errs, fmt.Errorf("%w: machine id %d by %s", &ErrorMachineIdInUse,
+				machineId, strings.Join(hosts[machineId], ", ")))
+		}
+	}
+
+	return errors.Join(errs...)
+}
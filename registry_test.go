@@ -0,0 +1,115 @@
+package snowflake
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewRegisteredGenerator(t *testing.T) {
+	first, err := NewRegisteredGenerator("arn", 35)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Same machine id, same region or not.
+	for _, region := range []string{"arn", "fra"} {
+		if _, err := NewRegisteredGenerator(region, 35); !errors.Is(err, &ErrorMachineIdInUse) {
+			t.Errorf("got '%v', want '%v'", err, &ErrorMachineIdInUse)
+		}
+	}
+
+	other, err := NewRegisteredGenerator("iad", 35)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := NewRegisteredGenerator("fra", 35)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Closing twice does not release the new registration.
+	_ = first.Close()
+	if _, err := NewRegisteredGenerator("arn", 35); !errors.Is(err, &ErrorMachineIdInUse) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorMachineIdInUse)
+	}
+
+	if _, err := NewRegisteredGenerator("nowhere", 35); !errors.Is(err, &ErrorInvalidMachineId) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalidMachineId)
+	}
+
+	_ = second.Close()
+	_ = other.Close()
+}
+
+func TestRegisteredGeneratorSetMachineId(t *testing.T) {
+	g, err := NewRegisteredGenerator("arn", 36)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Moving away would leave the registration behind on the old id.
+	if err := g.SetMachineId("arn", 37); !errors.Is(err, &ErrorInvalidConfig) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalidConfig)
+	}
+
+	want, _ := newMachineId("arn", 36)
+	if id, err := g.Generate(); err != nil || id.MachineId() != want {
+		t.Errorf("got machine '%d' (%v), want '%d'", id.MachineId(), err, want)
+	}
+
+	// The registration still guards the original machine id.
+	if _, err := NewRegisteredGenerator("arn", 36); !errors.Is(err, &ErrorMachineIdInUse) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorMachineIdInUse)
+	}
+
+	_ = g.Close()
+}
+
+func TestValidateAssignments(t *testing.T) {
+	clean := map[string]int64{"arn/web-1": 1, "arn/web-2": 2, "iad/web-1": 1, "sin/web-1": 1}
+	if err := ValidateAssignments(clean); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	broken := map[string]int64{
+		"arn/web-1": 1,
+		"fra/web-2": 1, // same continent as arn
+		"arn/web-3": 1,
+		"arn/web-4": 64,
+		"xyz/web-5": 2,
+		"web-6":     3,
+		"iad/web-7": 4,
+	}
+
+	err := ValidateAssignments(broken)
+	if err == nil {
+		t.Fatalf("got nil error")
+	}
+
+	for _, want := range []error{&ErrorMachineIdInUse, &ErrorInvalidMachineId, &ErrorInvalidConfig} {
+		if !errors.Is(err, want) {
+			t.Errorf("got '%v', want '%v'", err, want)
+		}
+	}
+
+	// Every offending entry is listed, the valid one is not.
+	for _, key := range []string{"arn/web-1", "fra/web-2", "arn/web-3", "arn/web-4", "xyz/web-5", "web-6"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("got '%v', want '%s' listed", err, key)
+		}
+	}
+
+	if strings.Contains(err.Error(), "iad/web-7") {
+		t.Errorf("got '%v', want 'iad/web-7' not listed", err)
+	}
+
+	if got := len(err.(interface{ Unwrap() []error }).Unwrap()); got != 4 {
+		t.Errorf("got '%d', want '4' problems", got)
+	}
+}
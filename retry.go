@@ -0,0 +1,42 @@
+package snowflake
+
+import (
+	"errors"
+	"time"
+)
+
+// RetryingGenerator wraps a generator and transparently retries
+// generation on `ErrorClockBackwards`, which is usually transient, e.g.
+// after a small NTP adjustment. Implements `Source`.
+type RetryingGenerator struct {
+	generator *Generator
+	attempts  int
+	delay     time.Duration
+}
+
+// Creates a generator that tries up to attempts times, sleeping delay
+// between attempts, before surfacing the error. At least one attempt
+// is made.
+func NewRetryingGenerator(g *Generator, attempts int, delay time.Duration) *RetryingGenerator {
+	return &RetryingGenerator{generator: g, attempts: max(attempts, 1), delay: delay}
+}
+
+// Generates a unique snowflake id, retrying on clock drift. Any other
+// error, e.g. `ErrorClosed`, is returned immediately.
+func (r *RetryingGenerator) Generate() (ID, error) {
+	var err error
+
+	for i := 0; i < r.attempts; i++ {
+		if i > 0 {
+			time.Sleep(r.delay)
+		}
+
+		var id ID
+		id, err = r.generator.Generate()
+		if !errors.Is(err, &ErrorClockBackwards) {
+			return id, err
+		}
+	}
+
+	return Invalid, err
+}
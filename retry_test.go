@@ -0,0 +1,52 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryingGenerator(t *testing.T) {
+	tests := []struct {
+		attempts int
+		err      error
+	}{
+		{3, nil},
+		{2, &ErrorClockBackwards},
+		{0, &ErrorClockBackwards},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Retrying_%d", test.attempts), func(t *testing.T) {
+			// Every reading advances 1 ms, hence a drift of 3 ms
+			// recovers on the third reading.
+			clock := newFakeClock(time.Now())
+			g, _ := NewGenerator("arn", 35, WithClock(func() time.Time {
+				clock.Add(time.Millisecond)
+				return clock.Now()
+			}))
+
+			r := NewRetryingGenerator(g, test.attempts, time.Microsecond)
+			first, _ := r.Generate()
+			clock.Add(-3 * time.Millisecond)
+
+			id, err := r.Generate()
+			if !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			} else if err == nil && id <= first {
+				t.Errorf("got '%v', want greater than '%v'", int64(id), int64(first))
+			}
+		})
+	}
+}
+
+func TestRetryingGeneratorClosed(t *testing.T) {
+	g, _ := NewGenerator("arn", 35)
+	_ = g.Close()
+
+	var source Source = NewRetryingGenerator(g, 100, time.Second)
+	if _, err := source.Generate(); !errors.Is(err, &ErrorClosed) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorClosed)
+	}
+}
@@ -0,0 +1,37 @@
+package snowflake
+
+// Ring hands out pre-generated IDs from a fixed buffer and refills it
+// from its generator once drained, which amortizes the lock of the
+// generator across a batch without allocating. IDs are unique and
+// increasing, unless the generator uses a random sequence start, see
+// `Generator.SetRandomSequenceStart`. They carry the time of their
+// batch, not of `Next`.
+// Not safe for concurrent use.
+type Ring struct {
+	generator *Generator
+	ids       []ID
+	pos       int
+}
+
+// Creates a ring of the given size, at least 1, which is filled lazily.
+func NewRing(g *Generator, size int) *Ring {
+	ids := make([]ID, max(size, 1))
+	return &Ring{generator: g, ids: ids, pos: len(ids)}
+}
+
+// Returns the next ID of the ring, refilling it first if drained.
+// Panics if the generator fails, like the package level `Generate`.
+func (r *Ring) Next() ID {
+	if r.pos == len(r.ids) {
+		if err := r.generator.fill(r.ids); err != nil {
+			panic(err)
+		}
+
+		r.pos = 0
+	}
+
+	id := r.ids[r.pos]
+	r.pos++
+
+	return id
+}
@@ -0,0 +1,52 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	for _, size := range []int{1, 7, 5000} {
+		t.Run(fmt.Sprintf("Test_Ring_%d", size), func(t *testing.T) {
+			g, _ := NewGenerator("arn", 35)
+			r := NewRing(g, size)
+
+			seen := make(map[ID]bool)
+			previous := Invalid
+
+			// Drains and refills the ring several times, interleaved
+			// with direct generation. A refill beyond 4096 IDs spans
+			// several milliseconds.
+			for i := 0; i < 3*size+1; i++ {
+				id := r.Next()
+				if i%size == 0 {
+					direct, _ := g.Generate()
+					seen[direct] = true
+				}
+
+				if seen[id] {
+					t.Fatalf("duplicate id '%v'", int64(id))
+				} else if id <= previous {
+					t.Fatalf("got '%v' after '%v', want increasing", int64(id), int64(previous))
+				}
+
+				seen[id] = true
+				previous = id
+			}
+		})
+	}
+}
+
+func TestRingClosed(t *testing.T) {
+	g, _ := NewGenerator("arn", 35)
+	_ = g.Close()
+
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, &ErrorClosed) {
+			t.Errorf("got '%v', want '%v'", err, &ErrorClosed)
+		}
+	}()
+
+	NewRing(g, 8).Next()
+}
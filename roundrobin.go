@@ -0,0 +1,50 @@
+package snowflake
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// RoundRobinGenerator rotates among generators of distinct machine ids
+// reserved for a single process, multiplying the sequence headroom per
+// millisecond. IDs are unique, but within a millisecond not increasing
+// in the order they were minted. Implements `Source`.
+type RoundRobinGenerator struct {
+	generators []*Generator
+	turn       atomic.Uint64
+}
+
+// Creates a generator cycling through the given machine indices of the
+// region. Returns `ErrorInvalidConfig` if no or duplicate indices are
+// given and `ErrorInvalidMachineId` for invalid ones.
+// ATTENTION: Every index is occupied, none may be used elsewhere.
+func NewRoundRobinGenerator(region string, indices []int64) (*RoundRobinGenerator, error) {
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("%w: no machine indices", &ErrorInvalidConfig)
+	}
+
+	r := &RoundRobinGenerator{generators: make([]*Generator, len(indices))}
+	seen := make(map[int64]bool, len(indices))
+
+	for i, index := range indices {
+		if seen[index] {
+			return nil, fmt.Errorf("%w: duplicate machine index %d", &ErrorInvalidConfig, index)
+		}
+
+		g, err := NewGenerator(region, index)
+		if err != nil {
+			return nil, err
+		}
+
+		seen[index] = true
+		r.generators[i] = g
+	}
+
+	return r, nil
+}
+
+// Generates a unique snowflake id from the next generator in turn.
+func (r *RoundRobinGenerator) Generate() (ID, error) {
+	turn := r.turn.Add(1) - 1
+	return r.generators[turn%uint64(len(r.generators))].Generate()
+}
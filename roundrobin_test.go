@@ -0,0 +1,53 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRoundRobinGenerator(t *testing.T) {
+	r, err := NewRoundRobinGenerator("arn", []int64{3, 5, 8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	seen := make(map[ID]bool)
+
+	for i := 0; i < 3*10000; i++ {
+		id, err := r.Generate()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if seen[id] {
+			t.Fatalf("duplicate id '%v'", int64(id))
+		}
+
+		seen[id] = true
+
+		want, _ := newMachineId("arn", []int64{3, 5, 8}[i%3])
+		if id.MachineId() != want {
+			t.Fatalf("got machine '%d', want '%d'", id.MachineId(), want)
+		}
+	}
+}
+
+func TestRoundRobinGeneratorInvalid(t *testing.T) {
+	tests := []struct {
+		indices []int64
+		err     error
+	}{
+		{nil, &ErrorInvalidConfig},
+		{[]int64{3, 5, 3}, &ErrorInvalidConfig},
+		{[]int64{3, 64}, &ErrorInvalidMachineId},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_RoundRobinGenerator_%v", test.indices), func(t *testing.T) {
+			if _, err := NewRoundRobinGenerator("arn", test.indices); !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			}
+		})
+	}
+}
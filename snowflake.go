@@ -40,9 +40,14 @@
 package snowflake
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"math"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -65,81 +70,112 @@ const bitsMachineID int64 = 9
 // Number of bits to encode sequence number, if more than one ID was generated within the same millisecond.
 const bitsMachineSequence int64 = 12
 
+// Number of low sequence bits reserved for a tag, see `Generator.SetTag`.
+const bitsTag int64 = 4
+
 // Internal variables for snowflake ID generation.
 var epoch time.Time
-var machineId int64 = 0
-var machineSequence int64 = 0
+var bitMapMachineId, bitMapMachineSequence, bitMapTag int64
 
-var bitMapMachineId, bitMapMachineSequence int64
-var mutex sync.Mutex
-var previous int64
+// Generator backing the package level functions.
+var defaultGenerator = &Generator{now: time.Now}
 
 func init() {
 	// Sanity check if encoding fits in signed int64
-	if bitsTimestamp+bitsMachineID+bitsMachineSequence != 63 {
-		panic("invalid snowflake bit length")
+	if err := validateLayout(layout{bitsTimestamp, bitsMachineID, bitsMachineSequence}); err != nil {
+		panic(err)
 	}
 
-	// `epoch` is `Epoch` + monotonic information. A monotonic clock
-	// exclusively moves forward, unlike a wall clock that can be adjusted
-	// backwards. In such case, there is a chance of duplicate IDs.
-	now := time.Now()
-	epoch = now.Add(time.UnixMilli(Epoch).Sub(now))
+	epoch = monotonicEpoch(Epoch)
+	defaultGenerator.epoch = epoch
 
 	// Prepare bitmaps for bitwise operation
 	bitMapMachineId = int64(math.Pow(2, float64(bitsMachineID))) - 1
 	bitMapMachineSequence = int64(math.Pow(2, float64(bitsMachineSequence))) - 1
+	bitMapTag = int64(math.Pow(2, float64(bitsTag))) - 1
+
+	// Guard against a mis-edited alphabet before building the lookup.
+	if err := validateAlphabet(DefaultAlphabet); err != nil {
+		panic(err)
+	}
+
+	active.Store(newCodec(DefaultAlphabet, Scrambled, false))
+}
 
-	// Pre-populates `decodeMap` to speed up parsing.
-	initDecodeMap()
+// Bit widths of the snowflake fields.
+type layout struct {
+	timestamp, machineId, sequence int64
+}
+
+// Verifies that a layout fills exactly the 63 usable bits of an int64.
+func validateLayout(l layout) error {
+	sum := l.timestamp + l.machineId + l.sequence
+
+	if l.timestamp < 1 || l.machineId < 3 || l.sequence < 1 || sum != 63 {
+		return fmt.Errorf("%w: bitsTimestamp (%d) + bitsMachineID (%d) + bitsMachineSequence (%d) = %d, "+
+			"want 63 with at least 3 machine id bits for the continent",
+			&ErrorInvalidLayout, l.timestamp, l.machineId, l.sequence, sum)
+	}
+
+	return nil
 }
 
-// Sets the unique machine id for snowflake generation.
+// Returns the given Unix millisecond epoch + monotonic information.
+// A monotonic clock exclusively moves forward, unlike a wall clock that
+// can be adjusted backwards. In such case, there is a chance of duplicate IDs.
+func monotonicEpoch(ms int64) time.Time {
+	now := time.Now()
+	return now.Add(time.UnixMilli(ms).Sub(now))
+}
+
+// Sets the unique machine id for snowflake generation. Panics on an
+// unknown region or index, use `Default().SetMachineId` for an error.
 // ATTENTION: If more than one server is using the same
 // machine id in parallel, then the uniqueness of any
 // snowflake ID can _NOT_ be guaranteed.
 func SetMachineId(region string, index int64) {
+	if err := defaultGenerator.SetMachineId(region, index); err != nil {
+		panic("unable to determine proper machine id")
+	}
+}
+
+// Computes the 9 bit machine id from a region and machine index.
+func newMachineId(region string, index int64) (int64, error) {
 	continent := getContinentCode(region)
 	maxMachineNumber := int64(math.Pow(2, float64(bitsMachineID-3)))
 
+	if continent >= 0 && index >= 0 {
+		continent, index = resolveBorrowed(continent, index, maxMachineNumber)
+	}
+
 	if continent < 0 || index < 0 || index >= maxMachineNumber {
-		panic("unable to determine proper machine id")
+		return 0, &ErrorInvalidMachineId
 	}
 
-	machineId = ((continent & 0b111) << (bitsMachineID - 3)) | (index & (maxMachineNumber - 1))
+	return ((continent & 0b111) << (bitsMachineID - 3)) | (index & (maxMachineNumber - 1)), nil
 }
 
 // Generates a unique snowflake id.
 func Generate() ID {
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	now := time.Since(epoch).Milliseconds()
-
-	if now == previous && machineSequence == bitMapMachineSequence {
-		// Reached max squence number 2^{BitsMachineSequence}.
-		// Wait for the next millisecond.
-		for now <= previous {
-			now = time.Since(epoch).Milliseconds()
-		}
-	} else if now > previous {
-		// Reset machine sequence for new millisecond
-		machineSequence = -1
-	} else if now < previous {
-		// Avoid potential duplicates
-		panic("attempted to generate snowflake id of the past")
+	id, err := defaultGenerator.Generate()
+	if err != nil {
+		panic(err)
 	}
 
-	// Increment machine sequence
-	machineSequence = (machineSequence + 1) & bitMapMachineSequence
+	return id
+}
 
-	// Update latest ID timestamp
-	previous = now
+// Generates a unique snowflake id without ever waiting, returning
+// `ErrorSequenceExhausted` if the current millisecond is exhausted.
+func TryGenerate() (ID, error) {
+	return defaultGenerator.TryGenerate()
+}
 
-	// Return snowflake
-	return ID(now<<(bitsMachineID+bitsMachineSequence) |
-		(machineId << bitsMachineSequence) |
-		machineSequence)
+// Generates a unique snowflake id along with its base encoded
+// representation, since callers almost always need both.
+func GenerateString() (ID, string) {
+	id := Generate()
+	return id, id.String()
 }
 
 // Returns the base encoded representation of a snowflake ID.
@@ -157,9 +193,221 @@ func Parse(input string) (ID, error) {
 	return decode54([]byte(input))
 }
 
+// Converts a base encoded string into a snowflake ID without any
+// validation, for trusted internal pipelines only. ATTENTION: Unsafe for
+// untrusted input, invalid bytes or overflows yield garbage, not errors.
+func ParseTrusted(input string) ID {
+	decodeMap := &active.Load().decodeMap
+
+	var id int64
+	for i := 0; i < len(input); i++ {
+		id = id*54 + int64(decodeMap[input[i]])
+	}
+
+	return ID(id)
+}
+
+// Converts a base encoded string into a snowflake ID like `Parse`, but
+// reports `ErrorReservedBitSet` if the unused high bit would be set,
+// distinguishing corrupted IDs from generic garbage.
+func ParseStrict(input string) (ID, error) {
+	id, err := Parse(input)
+
+	// 11 chars never exceed 64 bits, hence an overflow means the high bit is set.
+	if len(input) == 11 && errors.Is(err, &ErrorInvalid) {
+		return Invalid, &ErrorReservedBitSet
+	}
+
+	return id, err
+}
+
+// Converts a base encoded string into a snowflake ID like `Parse`, but
+// rejects inputs shorter than `min` characters with `ErrorInvalid`, e.g.
+// with 11 to catch truncated IDs where real ones always have full width.
+func ParseMinLen(input string, min int) (ID, error) {
+	if len(input) < min {
+		return Invalid, fmt.Errorf("%w: %d chars, want at least %d", &ErrorInvalid, len(input), min)
+	}
+
+	return Parse(input)
+}
+
+// Converts either a decimal or a base encoded string into a snowflake ID.
+// ATTENTION: All ten digits are part of the base 54 alphabet, hence a
+// string like "2024" is valid in both encodings. Strings consisting
+// only of digits are treated as decimal if they fit into an int64,
+// everything else is decoded as base 54. Real base 54 IDs contain
+// letters with overwhelming probability, short ones may be misread.
+func ParseAny(input string) (ID, error) {
+	if len(input) > 0 && strings.Trim(input, "0123456789") == "" {
+		if id, err := ParseNumberString(input); err == nil {
+			return id, nil
+		}
+	}
+
+	return Parse(input)
+}
+
+// Converts a base encoded string into a raw int64 snowflake,
+// for storage code that never deals with the `ID` type.
+func ParseInt64(input string) (int64, error) {
+	id, err := Parse(input)
+	return int64(id), err
+}
+
+// Returns the decimal representation of a snowflake. Intended for
+// clients such as JavaScript that lose precision on int64 numbers
+// above 2^53 and therefore need the numeric value as a string.
+func (id ID) NumberString() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// Converts a decimal string into a snowflake ID.
+func ParseNumberString(input string) (ID, error) {
+	id, err := strconv.ParseInt(input, 10, 64)
+	if err != nil || id < 0 {
+		return Invalid, &ErrorInvalid
+	}
+
+	return ID(id), nil
+}
+
 // Extracts timestamp from a snowflake.
 func (id ID) Time() int64 {
-	return (int64(id) >> (bitsMachineID + bitsMachineSequence)) + Epoch
+	return id.EpochMillis() + Epoch
+}
+
+// Extracts the raw timestamp bits from a snowflake, i.e.
+// milliseconds since Epoch instead of Unix milliseconds.
+func (id ID) EpochMillis() int64 {
+	return int64(id) >> (bitsMachineID + bitsMachineSequence)
+}
+
+// Compares two snowflakes minted with different epochs by their absolute
+// timestamps, returning -1, 0 or +1. Snowflakes of the same millisecond
+// compare equal, since their sequences are unrelated.
+func CompareAcrossEpochs(a ID, epochA time.Time, b ID, epochB time.Time) int {
+	return cmp.Compare(epochA.UnixMilli()+a.EpochMillis(), epochB.UnixMilli()+b.EpochMillis())
+}
+
+// Composes the snowflake with sequence `rank` for the given millisecond
+// and machine, e.g. for fully reproducible IDs in snapshot tests.
+func IDAtRank(t time.Time, region string, index, rank int64) (ID, error) {
+	machineId, err := newMachineId(region, index)
+	if err != nil {
+		return Invalid, err
+	} else if rank < 0 || rank > bitMapMachineSequence {
+		return Invalid, &ErrorInvalidSequence
+	}
+
+	id := IDFromEpochMillis(t.UnixMilli()-Epoch, machineId, rank)
+	if id == Invalid {
+		return Invalid, &ErrorInvalid
+	}
+
+	return id, nil
+}
+
+// Returns the age of a snowflake relative to Epoch as a duration.
+func (id ID) SinceEpoch() time.Duration {
+	return time.Duration(id.EpochMillis()) * time.Millisecond
+}
+
+// Compares the configured Epoch against an expected value, e.g. from
+// fleet wide config, to catch misconfigured deploys at startup, whose
+// IDs would sort wrong. Compared with millisecond precision.
+func VerifyEpoch(expected time.Time) error {
+	if expected.UnixMilli() != epoch.UnixMilli() {
+		return fmt.Errorf("%w: got %v, want %v", &ErrorEpochMismatch,
+			time.UnixMilli(epoch.UnixMilli()).UTC(), expected.UTC())
+	}
+
+	return nil
+}
+
+// Returns the time left until the timestamp overflows for Epoch, i.e.
+// until 2159-05-15. Negative once the ceiling has passed.
+func TimeUntilOverflow() time.Duration {
+	return time.Until(epoch.Add(time.Duration(1<<bitsTimestamp) * time.Millisecond))
+}
+
+// Returns the number of full days since Epoch, e.g. as a stable suffix
+// for daily partitioned tables. Days start at 00:00:01 UTC like Epoch.
+func (id ID) DayBucket() int64 {
+	return id.EpochMillis() / (24 * time.Hour).Milliseconds()
+}
+
+// Classifies a snowflake by its age, returning the index of the first
+// band the age is below, or `len(bands)` if it is older than all, e.g.
+// for tiered storage with bands 1h, 24h and 720h. Expects sorted bands.
+func (id ID) AgeBand(bands ...time.Duration) int {
+	age := time.Since(time.UnixMilli(id.Time()))
+
+	for i, band := range bands {
+		if age < band {
+			return i
+		}
+	}
+
+	return len(bands)
+}
+
+// Returns the age of a snowflake as a short relative string truncated to
+// the largest unit, e.g. "45s ago", "3m ago", "5h ago" or "2d ago". IDs
+// from the future due to clock skew are reported as "0s ago".
+func (id ID) Age() string {
+	age := max(time.Since(time.UnixMilli(id.Time())), 0)
+
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds ago", age/time.Second)
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", age/time.Minute)
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", age/time.Hour)
+	}
+
+	return fmt.Sprintf("%dd ago", age/(24*time.Hour))
+}
+
+// Composes a snowflake from milliseconds since Epoch, machine id
+// and sequence. Returns `Invalid` if any field is out of range.
+func IDFromEpochMillis(ms, machineId, sequence int64) ID {
+	if ms < 0 || ms >= 1<<bitsTimestamp ||
+		machineId < 0 || machineId > bitMapMachineId ||
+		sequence < 0 || sequence > bitMapMachineSequence {
+		return Invalid
+	}
+
+	return ID(ms<<(bitsMachineID+bitsMachineSequence) | machineId<<bitsMachineSequence | sequence)
+}
+
+// Returns the largest legal snowflake of the layout, i.e. the last
+// sequence of the last machine at the timestamp ceiling. Equals
+// `math.MaxInt64` for the default layout.
+func MaxID() ID {
+	return IDFromEpochMillis(1<<bitsTimestamp-1, bitMapMachineId, bitMapMachineSequence)
+}
+
+// Returns the smallest legal snowflake, i.e. the first sequence of
+// machine 0 at the Epoch instant.
+func MinID() ID {
+	return IDFromEpochMillis(0, 0, 0)
+}
+
+// Returns the smallest snowflake minted at the given time, e.g. as the
+// lower bound of a range scan. Returns `Invalid` for times before Epoch
+// or beyond the timestamp ceiling.
+func MinIDForTime(t time.Time) ID {
+	return IDFromEpochMillis(t.UnixMilli()-Epoch, 0, 0)
+}
+
+// Returns the number of base 54 characters of IDs minted at the given
+// time, e.g. to size UI columns. Every ID minted after 2023-03-09 takes
+// 11 chars, as do all in `Sortable` mode. Returns 0 for times out of
+// range, see `MinIDForTime`.
+func EncodedLenForTime(t time.Time) int {
+	return MinIDForTime(t).EncodedLen()
 }
 
 // Extracts machine id from a snowflake.
@@ -167,11 +415,226 @@ func (id ID) MachineId() int64 {
 	return (int64(id) >> bitsMachineSequence) & bitMapMachineId
 }
 
+// Extracts the 3 bit continent code from a snowflake in a single
+// step, e.g. for coarse geographic routing, see `RegionFor`.
+func (id ID) ContinentCode() int64 {
+	return (int64(id) >> (bitsMachineSequence + bitsMachineID - 3)) & 0b111
+}
+
+// Returns a short key identifying the producing machine, continent and
+// index separated by a dot, e.g. "5.35", so that all IDs of a machine
+// share a rate limiter. Independent of time and sequence.
+func (id ID) RateKey() string {
+	var b [7]byte
+	key := strconv.AppendInt(b[:0], id.ContinentCode(), 10)
+	key = append(key, '.')
+	key = strconv.AppendInt(key, id.MachineId()&(1<<(bitsMachineID-3)-1), 10)
+
+	return string(key)
+}
+
 // Extracts sequence number from a snowflake.
 func (id ID) MachineSequence() int64 {
 	return int64(id) & bitMapMachineSequence
 }
 
+// Verifies that the reserved high bit of a snowflake is not set,
+// which would make it negative, e.g. for IDs from untrusted int64s.
+func (id ID) Validate() error {
+	if id < 0 {
+		return &ErrorReservedBitSet
+	}
+
+	return nil
+}
+
+// Splits a snowflake into its raw bit fields. Unlike `Time`, the
+// timestamp is the number of milliseconds since Epoch.
+func (id ID) Decompose() (timestamp, machineId, sequence int64) {
+	return id.EpochMillis(), id.MachineId(), id.MachineSequence()
+}
+
+// Structured fields of a snowflake, see `ID.Parts`.
+type Parts struct {
+	Time      time.Time `json:"time"`
+	Continent int64     `json:"continent"`
+	Machine   int64     `json:"machine"`
+	Sequence  int64     `json:"sequence"`
+	Raw       int64     `json:"raw"`
+}
+
+// Splits a snowflake into its fields, e.g. for admin endpoints. Machine
+// is the machine index within the continent, i.e. the low 6 machine id bits.
+func (id ID) Parts() Parts {
+	return Parts{
+		Time:      time.UnixMilli(id.Time()).UTC(),
+		Continent: id.ContinentCode(),
+		Machine:   id.MachineId() & (1<<(bitsMachineID-3) - 1),
+		Sequence:  id.MachineSequence(),
+		Raw:       int64(id),
+	}
+}
+
+// Returns the binary layout of a snowflake for debugging, e.g.
+// `timestamp=0b...(42) machine=0b...(9) sequence=0b...(12)`.
+func (id ID) Debug() string {
+	timestamp, machineId, sequence := id.Decompose()
+
+	return fmt.Sprintf("timestamp=0b%0*b(%d) machine=0b%0*b(%d) sequence=0b%0*b(%d)",
+		bitsTimestamp, timestamp, bitsTimestamp,
+		bitsMachineID, machineId, bitsMachineID,
+		bitsMachineSequence, sequence, bitsMachineSequence)
+}
+
+// Returns a fingerprint of three emojis, e.g. "👌👤👦", to visually match
+// IDs in logs. Derived from the 21 machine id and sequence bits only, each
+// emoji encodes 7 bits from the 128 pictographs starting at U+1F400.
+// The bits are scrambled bijectively, hence IDs of the same millisecond
+// never share a fingerprint, but sequential IDs still look different.
+func (id ID) Emoji() string {
+	v := mix21(uint64(id))
+
+	return string([]rune{
+		rune(0x1F400 + v>>14&0x7f),
+		rune(0x1F400 + v>>7&0x7f),
+		rune(0x1F400 + v&0x7f),
+	})
+}
+
+// Estimates the share of the sequence space of the ID's millisecond that
+// was used up to and including this ID, i.e. `(MachineSequence()+1)/4096`.
+// Applied to the last ID of a millisecond it is the saturation of that
+// millisecond, 1 meaning exhausted. Meaningless for generators using
+// `WithRandomSequenceStart`, `WithTag` or `WithMachineBits`.
+func (id ID) SaturationAt() float64 {
+	return float64(id.MachineSequence()+1) / float64(bitMapMachineSequence+1)
+}
+
+// Returns the sequence difference `other - id` and whether both
+// snowflakes share timestamp and machine id, i.e. how many IDs
+// could have been minted in between. Helps to detect dropped events.
+func (id ID) SequenceDelta(other ID) (int64, bool) {
+	if int64(id)>>bitsMachineSequence != int64(other)>>bitsMachineSequence {
+		return 0, false
+	}
+
+	return other.MachineSequence() - id.MachineSequence(), true
+}
+
+// Reports whether two snowflakes share timestamp and machine id, i.e.
+// stem from the same sequence window of a single machine, regardless of
+// their sequence. Equal IDs in particular indicate reuse or replay.
+// Always false for invalid IDs.
+func CouldBeDuplicate(a, b ID) bool {
+	return a >= 0 && b >= 0 && int64(a)>>bitsMachineSequence == int64(b)>>bitsMachineSequence
+}
+
+// Derives a storage shard in [0, numShards) from the machine id,
+// i.e. continent and machine index. The timestamp is deliberately
+// ignored, so all IDs of a machine map to the same shard forever and
+// there are no hot shards over time. Machine ids are hashed, hence
+// shards are evenly distributed across machines, but a single busy
+// machine still makes a busy shard. Returns 0 if numShards < 1.
+func (id ID) Shard(numShards int) int {
+	if numShards < 1 {
+		return 0
+	}
+
+	// Fibonacci hashing spreads low machine indices of all continents.
+	h := uint64(id.MachineId()) * 0x9E3779B97F4A7C15
+	return int((h >> 32) % uint64(numShards))
+}
+
+// Derives a partition in [0, ring) from all bits of the snowflake, e.g.
+// for a consistent hashing ring of cache nodes. Unlike `Shard` and plain
+// modulo, consecutive IDs of a single machine are spread across the ring.
+// Returns 0 if ring is 0.
+func (id ID) Partition(ring uint32) uint32 {
+	// Maps the high 32 bits onto the ring without a division.
+	return uint32((mix64(uint64(id)) >> 32) * uint64(ring) >> 32)
+}
+
+// Reports whether a snowflake belongs to a deterministic sample of
+// roughly 1 in rate IDs, e.g. for trace sampling consistent across
+// services. All bits are hashed, hence the sample has no time bias.
+// Keeps every ID if rate < 2.
+func (id ID) Sample(rate int) bool {
+	if rate < 2 {
+		return true
+	}
+
+	return mix64(uint64(id))%uint64(rate) == 0
+}
+
+// splitmix64 finalizer, every input bit affects every output bit.
+func mix64(h uint64) uint64 {
+	h = (h ^ (h >> 30)) * 0xBF58476D1CE4E5B9
+	h = (h ^ (h >> 27)) * 0x94D049BB133111EB
+	return h ^ (h >> 31)
+}
+
+// Scrambles the 21 machine id and sequence bits bijectively, the odd
+// multiplier and the xorshift are both invertible modulo 2^21.
+func mix21(h uint64) uint64 {
+	const mask = 1<<(bitsMachineID+bitsMachineSequence) - 1
+
+	h = (h & mask) * 0x9E3779B1 & mask
+	return h ^ (h >> 11)
+}
+
+// Returns a copy of the snowflake with its time truncated to the full
+// UTC hour, keeping machine id and sequence, e.g. for logs that must not
+// leak precise creation times but need a stable identifier.
+// ATTENTION: Lossy, the redacted ID does not round-trip to the original
+// and may collide with other IDs of the same machine in that hour.
+func (id ID) Redacted() ID {
+	if id < 0 {
+		return Invalid
+	}
+
+	const hour = int64(time.Hour / time.Millisecond)
+	ms := max(id.Time()-id.Time()%hour-Epoch, 0)
+
+	return IDFromEpochMillis(ms, id.MachineId(), id.MachineSequence())
+}
+
+// Extracts the tag from a snowflake minted by a tagged generator.
+// The result is meaningless for untagged snowflakes.
+func (id ID) Tag() int64 {
+	return int64(id) & bitMapTag
+}
+
+// Heuristically determines whether an integer looks like a snowflake
+// rather than e.g. a legacy auto-increment value, to route records
+// during dual-write migrations. The timestamp has to be at least a day
+// after Epoch and at most an hour ahead of now, and the continent valid.
+// This is NOT authoritative, any int64 is a syntactically valid snowflake.
+func LooksLikeSnowflake(v int64) bool {
+	if v <= 0 {
+		return false
+	}
+
+	id := ID(v)
+	ts := id.Time()
+
+	return ts >= Epoch+24*time.Hour.Milliseconds() &&
+		ts <= time.Now().Add(time.Hour).UnixMilli() &&
+		id.MachineId()>>(bitsMachineID-3) < int64(len(continents))
+}
+
+// Returns a copy of the snowflake with its machine id replaced,
+// preserving timestamp and sequence. Useful to relabel IDs when
+// migrating data between differently configured clusters.
+func (id ID) WithMachine(region string, index int64) (ID, error) {
+	machine, err := newMachineId(region, index)
+	if err != nil {
+		return Invalid, err
+	}
+
+	cleared := int64(id) &^ (bitMapMachineId << bitsMachineSequence)
+	return ID(cleared | (machine << bitsMachineSequence)), nil
+}
+
 //
 // Marshaler interface implementation
 //
@@ -196,3 +659,41 @@ func (id *ID) UnmarshalJSON(b []byte) error {
 	*id = parsed
 	return nil
 }
+
+// Decodes a JSON array of base encoded IDs token by token, calling fn for
+// every ID without buffering the whole array, e.g. for multi-megabyte
+// payloads. Errors are annotated with the index of the offending element.
+func DecodeJSONArray(r io.Reader, fn func(ID) error) error {
+	dec := json.NewDecoder(r)
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return &ErrorInvalidJson
+	}
+
+	for i := 0; dec.More(); i++ {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("element %d: %w: %w", i, &ErrorInvalidJson, err)
+		}
+
+		s, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("element %d: %w", i, &ErrorInvalidJson)
+		}
+
+		id, err := Parse(s)
+		if err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+
+		if err := fn(id); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim(']') {
+		return &ErrorInvalidJson
+	}
+
+	return nil
+}
@@ -1,11 +1,16 @@
 package snowflake
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestGenerate(t *testing.T) {
@@ -50,8 +55,130 @@ func TestMachineId(t *testing.T) {
 	for _, test := range tests {
 		t.Run(fmt.Sprintf("Test_MachineID_%s_%d", test.region, test.num), func(t *testing.T) {
 			SetMachineId(test.region, test.num)
-			fmt.Println("Machine ID: ", machineId)
-			fmt.Printf("Binary:      %09b\n", machineId)
+			fmt.Println("Machine ID: ", defaultGenerator.machineId)
+			fmt.Printf("Binary:      %09b\n", defaultGenerator.machineId)
+		})
+	}
+}
+
+func TestContinentCode(t *testing.T) {
+	g, _ := NewGenerator("arn", 35)
+	europe, _ := g.Generate()
+
+	g, _ = NewGenerator("iad", 63)
+	america, _ := g.Generate()
+
+	tests := []struct {
+		id     ID
+		verify int64
+	}{
+		{europe, 5},
+		{america, 2},
+		{IDFromEpochMillis(1, 0b111<<6|63, 4095), 7},
+		{IDFromEpochMillis(1<<41, 0, 4095), 0},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ContinentCode_%d", int64(test.id)), func(t *testing.T) {
+			if got := test.id.ContinentCode(); got != test.verify {
+				t.Errorf("got '%d', want '%d'", got, test.verify)
+			}
+		})
+	}
+}
+
+func TestRateKey(t *testing.T) {
+	g, _ := NewGenerator("arn", 35)
+	first, _ := g.Generate()
+	second, _ := g.Generate()
+
+	tests := []struct {
+		id     ID
+		verify string
+	}{
+		{first, "5.35"},
+		{second, "5.35"},
+		{IDFromEpochMillis(987654321, 5<<6|35, 17), "5.35"},
+		{IDFromEpochMillis(1, 5<<6|36, 0), "5.36"},
+		{IDFromEpochMillis(1, 2<<6|35, 0), "2.35"},
+		{IDFromEpochMillis(1, 0b111<<6|63, 4095), "7.63"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_RateKey_%d", int64(test.id)), func(t *testing.T) {
+			if got := test.id.RateKey(); got != test.verify {
+				t.Errorf("got '%s', want '%s'", got, test.verify)
+			}
+		})
+	}
+
+	if n := testing.AllocsPerRun(100, func() { _ = first.RateKey() }); n > 1 {
+		t.Errorf("got %v allocs, want at most 1", n)
+	}
+}
+
+func TestWithMachine(t *testing.T) {
+	SetMachineId("fra", 35)
+	id := Generate()
+
+	tests := []struct {
+		region string
+		num    int64
+		err    error
+	}{
+		{"lax", 4, nil},
+		{"syd", 63, nil},
+		{"fra", 35, nil},
+		{"unk", 0, &ErrorInvalidMachineId},
+		{"phx", 64, &ErrorInvalidMachineId},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_WithMachine_%s_%d", test.region, test.num), func(t *testing.T) {
+			relabeled, err := id.WithMachine(test.region, test.num)
+
+			if test.err != nil {
+				if !errors.Is(err, test.err) || relabeled != Invalid {
+					t.Errorf("got '%v' and '%v', want '%v'", int64(relabeled), err, test.err)
+				}
+				return
+			}
+
+			want, _ := newMachineId(test.region, test.num)
+
+			if err != nil {
+				t.Errorf("relabel failed: %v", err)
+			} else if relabeled.MachineId() != want {
+				t.Errorf("got machine '%d', want '%d'", relabeled.MachineId(), want)
+			} else if relabeled.Time() != id.Time() || relabeled.MachineSequence() != id.MachineSequence() {
+				t.Errorf("timestamp or sequence changed: got '%v', want '%v'", int64(relabeled), int64(id))
+			}
+		})
+	}
+}
+
+func TestValidateLayout(t *testing.T) {
+	tests := []struct {
+		layout layout
+		err    error
+	}{
+		{layout{42, 9, 12}, nil},
+		{layout{41, 10, 12}, nil},
+		{layout{42, 9, 13}, &ErrorInvalidLayout},
+		{layout{42, 9, 11}, &ErrorInvalidLayout},
+		{layout{49, 2, 12}, &ErrorInvalidLayout},
+		{layout{63, 0, 0}, &ErrorInvalidLayout},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Layout_%d_%d_%d", test.layout.timestamp, test.layout.machineId, test.layout.sequence), func(t *testing.T) {
+			err := validateLayout(test.layout)
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			} else if err != nil && !strings.Contains(err.Error(), fmt.Sprintf("(%d)", test.layout.sequence)) {
+				t.Errorf("got '%v', want the bit widths reported", err)
+			}
 		})
 	}
 }
@@ -86,6 +213,652 @@ func BenchmarkGenerate(b *testing.B) {
 	}
 }
 
+func TestNumberString(t *testing.T) {
+	tests := []struct {
+		id     ID
+		verify string
+	}{
+		{ID(0), "0"},
+		{ID(123123), "123123"},
+		{ID(305023354946072576), "305023354946072576"},
+		{ID(9223372036854775807), "9223372036854775807"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_NumberString_%d", int64(test.id)), func(t *testing.T) {
+			encoded := test.id.NumberString()
+			parsed, err := ParseNumberString(encoded)
+
+			if encoded != test.verify {
+				t.Errorf("got '%s', want '%s'", encoded, test.verify)
+			} else if err != nil || parsed != test.id {
+				t.Errorf("got '%v' (%v), want '%v'", int64(parsed), err, int64(test.id))
+			}
+		})
+	}
+}
+
+func TestParseNumberStringInvalid(t *testing.T) {
+	tests := []string{"", "-1", "8uyZY2sj3re", "9223372036854775808", " 123"}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseNumberString_%s", test), func(t *testing.T) {
+			id, err := ParseNumberString(test)
+
+			if !errors.Is(err, &ErrorInvalid) || id != Invalid {
+				t.Errorf("got '%v' (%v), want '%v'", int64(id), err, &ErrorInvalid)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		v   int64
+		err error
+	}{
+		{0, nil},
+		{305023354946072576, nil},
+		{9223372036854775807, nil},
+		{-1, &ErrorReservedBitSet},
+		{305023354946072576 | -1<<63, &ErrorReservedBitSet},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Validate_%d", test.v), func(t *testing.T) {
+			if err := ID(test.v).Validate(); !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			}
+		})
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	id := ID(305023354946072576)
+	timestamp, machineId, sequence := id.Decompose()
+
+	if timestamp+Epoch != id.Time() || machineId != id.MachineId() || sequence != id.MachineSequence() {
+		t.Errorf("got '%d/%d/%d', want '%d/%d/%d'", timestamp+Epoch, machineId, sequence,
+			id.Time(), id.MachineId(), id.MachineSequence())
+	}
+}
+
+func TestParts(t *testing.T) {
+	tests := []struct {
+		id     ID
+		verify Parts
+	}{
+		// Example of the package documentation.
+		{ID(305023354946072576), Parts{time.Date(2024, time.August, 10, 9, 47, 50, 758e6, time.UTC), 0, 35, 0, 305023354946072576}},
+		{IDFromEpochMillis(0, 5<<6|35, 4095), Parts{time.UnixMilli(Epoch).UTC(), 5, 35, 4095, int64(IDFromEpochMillis(0, 5<<6|35, 4095))}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Parts_%d", int64(test.id)), func(t *testing.T) {
+			if got := test.id.Parts(); got != test.verify {
+				t.Errorf("got '%+v', want '%+v'", got, test.verify)
+			}
+		})
+	}
+
+	b, _ := json.Marshal(ID(305023354946072576).Parts())
+	if want := `{"time":"2024-08-10T09:47:50.758Z","continent":0,"machine":35,"sequence":0,"raw":305023354946072576}`; string(b) != want {
+		t.Errorf("got '%s', want '%s'", b, want)
+	}
+}
+
+func TestEpochMillis(t *testing.T) {
+	tests := []ID{
+		ID(0),
+		ID(305023354946072576),
+		ID(9223372036854775807),
+		Generate(),
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_EpochMillis_%d", int64(test)), func(t *testing.T) {
+			ms := test.EpochMillis()
+			composed := IDFromEpochMillis(ms, test.MachineId(), test.MachineSequence())
+
+			if ms+Epoch != test.Time() {
+				t.Errorf("got '%d', want '%d'", ms+Epoch, test.Time())
+			} else if composed != test {
+				t.Errorf("got '%v', want '%v'", int64(composed), int64(test))
+			}
+		})
+	}
+
+	invalid := [][3]int64{{-1, 0, 0}, {1 << bitsTimestamp, 0, 0}, {0, 512, 0}, {0, 0, 4096}, {0, -1, 0}}
+	for _, test := range invalid {
+		if id := IDFromEpochMillis(test[0], test[1], test[2]); id != Invalid {
+			t.Errorf("got '%v' for %v, want '%v'", int64(id), test, int64(Invalid))
+		}
+	}
+}
+
+func TestIDAtRank(t *testing.T) {
+	at := time.Date(2024, time.August, 10, 9, 47, 50, 758000000, time.UTC)
+
+	tests := []struct {
+		t      time.Time
+		region string
+		index  int64
+		rank   int64
+		verify ID
+		err    error
+	}{
+		{at, "arn", 35, 0, ID(305023354946072576) | 5<<18, nil},
+		{at, "arn", 35, 4095, ID(305023354946072576) | 5<<18 | 4095, nil},
+		{at, "arn", 35, 4096, Invalid, &ErrorInvalidSequence},
+		{at, "arn", 35, -1, Invalid, &ErrorInvalidSequence},
+		{at, "unk", 35, 0, Invalid, &ErrorInvalidMachineId},
+		{time.UnixMilli(Epoch - 1), "arn", 35, 0, Invalid, &ErrorInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_IDAtRank_%s_%d", test.region, test.rank), func(t *testing.T) {
+			id, err := IDAtRank(test.t, test.region, test.index, test.rank)
+
+			if id != test.verify || !errors.Is(err, test.err) {
+				t.Errorf("got '%v' (%v), want '%v' (%v)", int64(id), err, int64(test.verify), test.err)
+			} else if err == nil && (id.Time() != test.t.UnixMilli() || id.MachineSequence() != test.rank) {
+				t.Errorf("got time '%d' and sequence '%d'", id.Time(), id.MachineSequence())
+			}
+		})
+	}
+}
+
+func TestSinceEpoch(t *testing.T) {
+	for _, id := range []ID{ID(0), ID(305023354946072576), Generate()} {
+		want := time.UnixMilli(id.Time()).Sub(time.UnixMilli(Epoch))
+
+		if got := id.SinceEpoch(); got != want {
+			t.Errorf("got '%v', want '%v'", got, want)
+		}
+	}
+}
+
+func TestDayBucket(t *testing.T) {
+	at := func(t time.Time) ID {
+		return IDFromEpochMillis(t.UnixMilli()-Epoch, 35, 0)
+	}
+
+	tests := []struct {
+		id     ID
+		verify int64
+	}{
+		{ID(305023354946072576), 1683}, // 2024-08-10
+		{at(time.Date(2024, time.August, 10, 0, 0, 1, 0, time.UTC)), 1683},
+		{at(time.Date(2024, time.August, 10, 0, 0, 0, 0, time.UTC)), 1682},
+		{ID(0), 0},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_DayBucket_%d", int64(test.id)), func(t *testing.T) {
+			if got := test.id.DayBucket(); got != test.verify {
+				t.Errorf("got '%d', want '%d'", got, test.verify)
+			}
+		})
+	}
+}
+
+func TestAgeBand(t *testing.T) {
+	bands := []time.Duration{time.Hour, 24 * time.Hour, 30 * 24 * time.Hour}
+	minted := func(age time.Duration) ID {
+		return IDFromEpochMillis(time.Now().Add(-age).UnixMilli()-Epoch, 35, 0)
+	}
+
+	tests := []struct {
+		id     ID
+		verify int
+	}{
+		{minted(time.Minute), 0},
+		{minted(2 * time.Hour), 1},
+		{minted(7 * 24 * time.Hour), 2},
+		{minted(365 * 24 * time.Hour), 3},
+		{ID(0), 3},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_AgeBand_%d", int64(test.id)), func(t *testing.T) {
+			if got := test.id.AgeBand(bands...); got != test.verify {
+				t.Errorf("got '%d', want '%d'", got, test.verify)
+			}
+		})
+	}
+
+	if got := minted(time.Minute).AgeBand(); got != 0 {
+		t.Errorf("got '%d', want '0'", got)
+	}
+}
+
+func TestEmoji(t *testing.T) {
+	base := IDFromEpochMillis(123456789, 0, 0)
+	seen := make(map[string]ID)
+
+	// Every machine and sequence combination yields its own fingerprint.
+	for machine := int64(0); machine <= bitMapMachineId; machine++ {
+		for sequence := int64(0); sequence <= bitMapMachineSequence; sequence += 15 {
+			id := IDFromEpochMillis(123456789, machine, sequence)
+			emoji := id.Emoji()
+
+			if other, ok := seen[emoji]; ok {
+				t.Fatalf("got '%s' for '%v' and '%v'", emoji, int64(id), int64(other))
+			} else if n := utf8.RuneCountInString(emoji); n != 3 {
+				t.Fatalf("got %d runes, want 3", n)
+			}
+
+			seen[emoji] = id
+		}
+	}
+
+	if got, want := base.Emoji(), IDFromEpochMillis(987654321, 0, 0).Emoji(); got != want {
+		t.Errorf("got '%s', want '%s'", got, want)
+	}
+
+	if got, want := IDFromEpochMillis(1, 35, 7).Emoji(), IDFromEpochMillis(1, 35, 7).Emoji(); got != want {
+		t.Errorf("got '%s', want '%s'", got, want)
+	}
+}
+
+func TestAge(t *testing.T) {
+	minted := func(age time.Duration) ID {
+		return IDFromEpochMillis(time.Now().Add(-age).UnixMilli()-Epoch, 35, 0)
+	}
+
+	tests := []struct {
+		id     ID
+		verify string
+	}{
+		{minted(-time.Hour), "0s ago"},
+		{minted(45*time.Second + 100*time.Millisecond), "45s ago"},
+		{minted(3*time.Minute + 10*time.Second), "3m ago"},
+		{minted(5*time.Hour + 10*time.Minute), "5h ago"},
+		{minted(2*24*time.Hour + time.Hour), "2d ago"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Age_%s", test.verify), func(t *testing.T) {
+			if got := test.id.Age(); got != test.verify {
+				t.Errorf("got '%s', want '%s'", got, test.verify)
+			}
+		})
+	}
+}
+
+func TestCompareAcrossEpochs(t *testing.T) {
+	epochA := time.UnixMilli(Epoch)
+	epochB := time.UnixMilli(1704067200000) // 2024-01-01
+
+	// Raw bits of b are smaller, but b was minted later.
+	a := IDFromEpochMillis(epochB.UnixMilli()-Epoch-1000, 35, 7)
+	b := IDFromEpochMillis(500, 35, 0)
+
+	tests := []struct {
+		a, b           ID
+		epochA, epochB time.Time
+		verify         int
+	}{
+		{a, b, epochA, epochB, -1},
+		{b, a, epochB, epochA, 1},
+		{a, a, epochA, epochA, 0},
+		{IDFromEpochMillis(1500, 1, 0), IDFromEpochMillis(500, 2, 9), epochA, epochA.Add(time.Second), 0},
+	}
+
+	if a < b {
+		t.Fatalf("raw comparison already orders '%v' before '%v'", int64(a), int64(b))
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("Test_CompareAcrossEpochs_%d", i), func(t *testing.T) {
+			if got := CompareAcrossEpochs(test.a, test.epochA, test.b, test.epochB); got != test.verify {
+				t.Errorf("got '%d', want '%d'", got, test.verify)
+			}
+		})
+	}
+}
+
+func TestDebug(t *testing.T) {
+	tests := []ID{
+		ID(0),
+		ID(305023354946072576),
+		ID(9223372036854775807),
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Debug_%d", int64(test)), func(t *testing.T) {
+			var timestamp, machineId, sequence string
+			var t1, t2, t3 int
+
+			_, err := fmt.Sscanf(test.Debug(), "timestamp=0b%42s(%d) machine=0b%9s(%d) sequence=0b%12s(%d)",
+				&timestamp, &t1, &machineId, &t2, &sequence, &t3)
+
+			if err != nil {
+				t.Fatalf("unexpected format '%s': %v", test.Debug(), err)
+			} else if len(timestamp) != 42 || len(machineId) != 9 || len(sequence) != 12 {
+				t.Errorf("got widths %d/%d/%d, want 42/9/12", len(timestamp), len(machineId), len(sequence))
+			} else if t1 != 42 || t2 != 9 || t3 != 12 {
+				t.Errorf("got labels %d/%d/%d, want 42/9/12", t1, t2, t3)
+			} else if timestamp+machineId+sequence != fmt.Sprintf("%063b", int64(test)) {
+				t.Errorf("got '%s', want '%063b'", timestamp+machineId+sequence, int64(test))
+			}
+		})
+	}
+}
+
+func TestSaturationAt(t *testing.T) {
+	tests := []struct {
+		id     ID
+		verify float64
+	}{
+		{IDFromEpochMillis(123456789, 35, 4095), 1},
+		{IDFromEpochMillis(123456789, 35, 4000), 4001.0 / 4096},
+		{IDFromEpochMillis(123456789, 35, 2047), 0.5},
+		{IDFromEpochMillis(123456789, 35, 0), 1.0 / 4096},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_SaturationAt_%d", test.id.MachineSequence()), func(t *testing.T) {
+			if got := test.id.SaturationAt(); got != test.verify {
+				t.Errorf("got '%v', want '%v'", got, test.verify)
+			}
+		})
+	}
+}
+
+func TestSequenceDelta(t *testing.T) {
+	id := ID(305023354946072576)
+	relabeled, _ := id.WithMachine("lax", 4)
+
+	tests := []struct {
+		name   string
+		other  ID
+		verify int64
+		ok     bool
+	}{
+		{"Same", id, 0, true},
+		{"Five", id + 5, 5, true},
+		{"NextMillisecond", id + 1<<(bitsMachineID+bitsMachineSequence), 0, false},
+		{"OtherMachine", relabeled + 5, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_SequenceDelta_%s", test.name), func(t *testing.T) {
+			delta, ok := id.SequenceDelta(test.other)
+
+			if delta != test.verify || ok != test.ok {
+				t.Errorf("got '%d' (%v), want '%d' (%v)", delta, ok, test.verify, test.ok)
+			}
+		})
+	}
+
+	if delta, ok := (id + 5).SequenceDelta(id); delta != -5 || !ok {
+		t.Errorf("got '%d' (%v), want '-5' (true)", delta, ok)
+	}
+}
+
+func TestCouldBeDuplicate(t *testing.T) {
+	id := IDFromEpochMillis(1000, 35, 7)
+
+	tests := []struct {
+		a, b   ID
+		verify bool
+	}{
+		{id, id, true},
+		{id, IDFromEpochMillis(1000, 35, 0), true},
+		{id, IDFromEpochMillis(1000, 35, 4095), true},
+		{id, IDFromEpochMillis(1000, 36, 7), false},
+		{id, IDFromEpochMillis(1001, 35, 7), false},
+		{Invalid, Invalid, false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_CouldBeDuplicate_%d_%d", int64(test.a), int64(test.b)), func(t *testing.T) {
+			if got := CouldBeDuplicate(test.a, test.b); got != test.verify {
+				t.Errorf("got '%v', want '%v'", got, test.verify)
+			}
+
+			if got := CouldBeDuplicate(test.b, test.a); got != test.verify {
+				t.Errorf("got '%v', want '%v' (swapped)", got, test.verify)
+			}
+		})
+	}
+}
+
+func TestShard(t *testing.T) {
+	for _, numShards := range []int{1, 3, 8, 16} {
+		t.Run(fmt.Sprintf("Test_Shard_%d", numShards), func(t *testing.T) {
+			counts := make([]int, numShards)
+
+			for machineId := int64(0); machineId <= bitMapMachineId; machineId++ {
+				g := &Generator{now: time.Now, epoch: epoch, machineId: machineId}
+				shard := -1
+
+				for i := 0; i < 10; i++ {
+					id, _ := g.Generate()
+					id += ID(i) << (bitsMachineID + bitsMachineSequence)
+
+					if shard >= 0 && id.Shard(numShards) != shard {
+						t.Fatalf("machine %d: got shard '%d', want '%d'", machineId, id.Shard(numShards), shard)
+					}
+
+					shard = id.Shard(numShards)
+				}
+
+				counts[shard]++
+			}
+
+			// 512 machine ids, roughly even.
+			for shard, count := range counts {
+				if want := 512 / numShards; count < want/2 || count > want*2 {
+					t.Errorf("got %d machines in shard %d, want ~%d", count, shard, want)
+				}
+			}
+		})
+	}
+
+	if shard := ID(305023354946072576).Shard(0); shard != 0 {
+		t.Errorf("got '%d', want '0'", shard)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	for _, ring := range []uint32{1, 7, 64} {
+		t.Run(fmt.Sprintf("Test_Partition_%d", ring), func(t *testing.T) {
+			counts := make([]int, ring)
+			base := IDFromEpochMillis(1000, 35, 0)
+
+			// Consecutive IDs of a single machine within 10 ms.
+			const n = 40960
+			for i := 0; i < n; i++ {
+				p := (base + ID(i/4096)<<(bitsMachineID+bitsMachineSequence) + ID(i%4096)).Partition(ring)
+				if p >= ring {
+					t.Fatalf("got '%d', want less than '%d'", p, ring)
+				}
+
+				counts[p]++
+			}
+
+			// Within 20% of uniform, i.e. 5 standard deviations for 64.
+			want := n / int(ring)
+			for p, count := range counts {
+				if count < want*8/10 || count > want*12/10 {
+					t.Errorf("got %d IDs in partition %d, want ~%d", count, p, want)
+				}
+			}
+		})
+	}
+
+	if p := ID(305023354946072576).Partition(0); p != 0 {
+		t.Errorf("got '%d', want '0'", p)
+	}
+}
+
+func TestSample(t *testing.T) {
+	for _, rate := range []int{1, 2, 10, 100} {
+		t.Run(fmt.Sprintf("Test_Sample_%d", rate), func(t *testing.T) {
+			base := IDFromEpochMillis(1000, 35, 0)
+
+			// Consecutive IDs of a single machine within 25 ms.
+			const n = 102400
+			kept := 0
+			for i := 0; i < n; i++ {
+				id := base + ID(i/4096)<<(bitsMachineID+bitsMachineSequence) + ID(i%4096)
+				if id.Sample(rate) {
+					kept++
+				}
+
+				if id.Sample(rate) != id.Sample(rate) {
+					t.Fatalf("got non-deterministic sample for '%d'", int64(id))
+				}
+			}
+
+			// Within 10% of 1/rate, i.e. 3 standard deviations for 100.
+			if want := n / rate; kept < want*9/10 || kept > want*11/10 {
+				t.Errorf("got '%d' kept, want ~'%d'", kept, want)
+			}
+		})
+	}
+
+	if !ID(305023354946072576).Sample(0) {
+		t.Errorf("got 'false', want 'true'")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	minted := time.Date(2024, time.August, 10, 9, 47, 50, 758e6, time.UTC)
+
+	tests := []struct {
+		id     ID
+		verify time.Time
+	}{
+		{IDFromEpochMillis(minted.UnixMilli()-Epoch, 5<<6|35, 17), time.Date(2024, time.August, 10, 9, 0, 0, 0, time.UTC)},
+		{IDFromEpochMillis(time.Date(2024, time.August, 10, 10, 0, 0, 0, time.UTC).UnixMilli()-Epoch, 0, 0), time.Date(2024, time.August, 10, 10, 0, 0, 0, time.UTC)},
+		{ID(0), time.UnixMilli(Epoch).UTC()}, // hour would predate Epoch
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Redacted_%d", int64(test.id)), func(t *testing.T) {
+			redacted := test.id.Redacted()
+
+			if got := time.UnixMilli(redacted.Time()).UTC(); !got.Equal(test.verify) {
+				t.Errorf("got '%v', want '%v'", got, test.verify)
+			} else if redacted.MachineId() != test.id.MachineId() || redacted.MachineSequence() != test.id.MachineSequence() {
+				t.Errorf("got '%d/%d', want '%d/%d'", redacted.MachineId(), redacted.MachineSequence(), test.id.MachineId(), test.id.MachineSequence())
+			}
+		})
+	}
+
+	if redacted := Invalid.Redacted(); redacted != Invalid {
+		t.Errorf("got '%v', want '%v'", int64(redacted), int64(Invalid))
+	}
+}
+
+func TestLooksLikeSnowflake(t *testing.T) {
+	SetMachineId("arn", 35)
+	future := ID(time.Now().Add(48*time.Hour).UnixMilli()-Epoch) << (bitsMachineID + bitsMachineSequence)
+
+	tests := []struct {
+		name   string
+		v      int64
+		verify bool
+	}{
+		{"Generated", int64(Generate()), true},
+		{"Documented", 305023354946072576, true},
+		{"Zero", 0, false},
+		{"Negative", -305023354946072576, false},
+		{"Sequential", 123123, false},
+		{"LargeSequential", 4294967296, false},
+		{"Future", int64(future), false},
+		{"InvalidContinent", 305023354946072576 | 0b111<<(bitsMachineSequence+bitsMachineID-3), false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_LooksLikeSnowflake_%s", test.name), func(t *testing.T) {
+			if got := LooksLikeSnowflake(test.v); got != test.verify {
+				t.Errorf("got '%v', want '%v'", got, test.verify)
+			}
+		})
+	}
+}
+
+func TestGenerateString(t *testing.T) {
+	SetMachineId("arn", 35)
+
+	for i := 0; i < 100; i++ {
+		id, encoded := GenerateString()
+		parsed, err := Parse(encoded)
+
+		if err != nil || parsed != id {
+			t.Fatalf("got '%v' (%v), want '%v'", int64(parsed), err, int64(id))
+		}
+	}
+}
+
+func BenchmarkGenerateString(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = GenerateString()
+	}
+}
+
+func BenchmarkGenerateThenString(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		id := Generate()
+		_ = id.String()
+	}
+}
+
+func TestGenerateParallelUnique(t *testing.T) {
+	const workers, n = 16, 10000
+
+	var wg sync.WaitGroup
+	results := make([][]ID, workers)
+
+	for j := 0; j < workers; j++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			results[j] = make([]ID, n)
+			for i := range results[j] {
+				results[j][i] = Generate()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	seen := make(map[ID]bool, workers*n)
+	for _, ids := range results {
+		for i, id := range ids {
+			if seen[id] {
+				t.Fatalf("duplicate id '%v'", int64(id))
+			} else if i > 0 && id <= ids[i-1] {
+				t.Fatalf("got '%v' after '%v', want increasing", int64(id), int64(ids[i-1]))
+			}
+
+			seen[id] = true
+		}
+	}
+}
+
+// Measures mutex contention with an increasing number of threads.
+// Throughput is capped at 4096 IDs per millisecond regardless.
+func BenchmarkGenerateParallel(b *testing.B) {
+	for _, procs := range []int{1, 2, 4, 8, 16} {
+		b.Run(fmt.Sprintf("procs=%d", procs), func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = Generate()
+				}
+			})
+		})
+	}
+}
+
 //
 // Marshaler interface implementation
 //
@@ -142,3 +915,121 @@ func TestUnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeJSONArray(t *testing.T) {
+	stop := errors.New("stop")
+
+	tests := []struct {
+		name   string
+		json   string
+		verify []ID
+		err    error
+		index  string
+	}{
+		{"Valid", `["6vF", "nHW1a", "8uyZY2sj3re"]`, []ID{123123, 123123123, 305023354946072576}, nil, ""},
+		{"Empty", `[]`, nil, nil, ""},
+		{"MalformedElement", `["6vF", "8uy!", "nHW1a"]`, []ID{123123}, &ErrorInvalidByte, "element 1"},
+		{"NumberElement", `["6vF", 123]`, []ID{123123}, &ErrorInvalidJson, "element 1"},
+		{"NotAnArray", `{"id": "6vF"}`, nil, &ErrorInvalidJson, ""},
+		{"Unterminated", `["6vF"`, []ID{123123}, &ErrorInvalidJson, ""},
+		{"Callback", `["6vF", "nHW1a", "8uyZY2sj3re"]`, []ID{123123, 123123123}, stop, "element 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_DecodeJSONArray_%s", test.name), func(t *testing.T) {
+			var ids []ID
+			err := DecodeJSONArray(strings.NewReader(test.json), func(id ID) error {
+				ids = append(ids, id)
+				if test.err == stop && len(ids) == 2 {
+					return stop
+				}
+
+				return nil
+			})
+
+			if !errors.Is(err, test.err) || (err != nil && !strings.Contains(err.Error(), test.index)) {
+				t.Errorf("got '%v', want '%v' at '%s'", err, test.err, test.index)
+			} else if fmt.Sprint(ids) != fmt.Sprint(test.verify) {
+				t.Errorf("got '%v', want '%v'", ids, test.verify)
+			}
+		})
+	}
+}
+
+func TestMaxMinID(t *testing.T) {
+	if MaxID() != ID(math.MaxInt64) {
+		t.Errorf("got '%v', want '%v'", int64(MaxID()), int64(math.MaxInt64))
+	}
+
+	if MinID() != ID(0) || MinID().Time() != Epoch {
+		t.Errorf("got '%v', want '%v'", MinID().Time(), Epoch)
+	}
+
+	for _, id := range []ID{MaxID(), MinID()} {
+		t.Run(fmt.Sprintf("Test_Bound_%v", int64(id)), func(t *testing.T) {
+			parsed, err := Parse(id.String())
+			if err != nil || parsed != id {
+				t.Errorf("got '%v' (%v), want '%v'", int64(parsed), err, int64(id))
+			}
+		})
+	}
+
+	if got := len(MaxID().String()); got != 11 {
+		t.Errorf("got '%v', want '%v'", got, 11)
+	}
+}
+
+func TestVerifyEpoch(t *testing.T) {
+	tests := []struct {
+		expected time.Time
+		err      error
+	}{
+		{time.Date(2020, time.January, 1, 0, 0, 1, 0, time.UTC), nil},
+		{time.UnixMilli(Epoch).In(time.FixedZone("CET", 3600)), nil},
+		{time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), &ErrorEpochMismatch},
+		{time.UnixMilli(TwitterEpoch), &ErrorEpochMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_VerifyEpoch_%d", test.expected.UnixMilli()), func(t *testing.T) {
+			if err := VerifyEpoch(test.expected); !errors.Is(err, test.err) {
+				t.Errorf("got '%v', want '%v'", err, test.err)
+			}
+		})
+	}
+}
+
+func TestEncodedLenForTime(t *testing.T) {
+	tests := []struct {
+		time   time.Time
+		verify int
+	}{
+		{time.Now(), 11},
+		{time.UnixMilli(Epoch), 1},
+		{time.Date(2023, time.March, 9, 13, 46, 20, 343e6, time.UTC), 10},
+		{time.Date(2023, time.March, 9, 13, 46, 20, 344e6, time.UTC), 11},
+		{time.UnixMilli(Epoch - 1), 0},
+		{time.Date(2200, time.January, 1, 0, 0, 0, 0, time.UTC), 0},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_EncodedLenForTime_%d", test.time.UnixMilli()), func(t *testing.T) {
+			if got := EncodedLenForTime(test.time); got != test.verify {
+				t.Errorf("got '%d', want '%d'", got, test.verify)
+			}
+		})
+	}
+
+	if id := MinIDForTime(time.UnixMilli(Epoch + 1)); id.EpochMillis() != 1 || id.MachineId() != 0 || id.MachineSequence() != 0 {
+		t.Errorf("got '%v', want '%v'", int64(id), 1<<21)
+	}
+}
+
+func TestTimeUntilOverflow(t *testing.T) {
+	ceiling := time.Date(2159, time.May, 15, 7, 35, 12, 104e6, time.UTC)
+	got := TimeUntilOverflow()
+
+	if got <= 0 || time.Until(ceiling)-got > time.Second {
+		t.Errorf("got '%v', want '%v'", got, time.Until(ceiling))
+	}
+}
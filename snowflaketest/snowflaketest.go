@@ -0,0 +1,37 @@
+// Package snowflaketest provides fakes for testing code that
+// depends on a snowflake.Source.
+package snowflaketest
+
+import (
+	"sync"
+
+	"github.com/eschmar/snowflake"
+)
+
+var ErrorExhausted = snowflake.SnowflakeError{Code: 0x300, Message: "static source exhausted"}
+
+// Source handing out a fixed list of IDs.
+type staticSource struct {
+	mutex sync.Mutex
+	ids   []snowflake.ID
+}
+
+// Returns a source yielding the given IDs in order, followed
+// by `ErrorExhausted` once all of them have been handed out.
+func StaticSource(ids ...snowflake.ID) snowflake.Source {
+	return &staticSource{ids: ids}
+}
+
+func (s *staticSource) Generate() (snowflake.ID, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.ids) == 0 {
+		return snowflake.Invalid, &ErrorExhausted
+	}
+
+	id := s.ids[0]
+	s.ids = s.ids[1:]
+
+	return id, nil
+}
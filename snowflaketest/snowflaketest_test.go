@@ -0,0 +1,50 @@
+package snowflaketest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/eschmar/snowflake"
+)
+
+// Example consumer accepting a source.
+func newOrder(src snowflake.Source) (string, error) {
+	id, err := src.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	return "ord_" + id.String(), nil
+}
+
+func TestStaticSource(t *testing.T) {
+	src := StaticSource(snowflake.ID(305023354946072576), snowflake.ID(123123))
+
+	tests := []struct {
+		verify string
+		err    error
+	}{
+		{"ord_8uyZY2sj3re", nil},
+		{"ord_6vF", nil},
+		{"", &ErrorExhausted},
+	}
+
+	for _, test := range tests {
+		order, err := newOrder(src)
+
+		if order != test.verify || !errors.Is(err, test.err) {
+			t.Errorf("got '%s' (%v), want '%s' (%v)", order, err, test.verify, test.err)
+		}
+	}
+}
+
+func TestSourceImplementations(t *testing.T) {
+	snowflake.SetMachineId("arn", 35)
+	g, _ := snowflake.NewGenerator("arn", 35)
+
+	for _, src := range []snowflake.Source{snowflake.Default(), g} {
+		if _, err := newOrder(src); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
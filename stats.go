@@ -0,0 +1,99 @@
+package snowflake
+
+import (
+	"slices"
+	"time"
+)
+
+// Returns the theoretical maximum number of IDs a single
+// machine can generate per second, 2^12 per millisecond.
+func MaxIDsPerSecond() int64 {
+	return (bitMapMachineSequence + 1) * 1000
+}
+
+// Returns how many IDs a single machine could still mint at
+// `MaxIDsPerSecond` until the timestamp overflows, see `TimeUntilOverflow`.
+// Returns 0 once the ceiling has passed.
+func IDsUntilOverflow() int64 {
+	// Per millisecond first, milliseconds times IDs per second overflow.
+	return max(TimeUntilOverflow().Milliseconds(), 0) * (MaxIDsPerSecond() / 1000)
+}
+
+// Returns the probability that at least two of the given number of
+// machines pick the same machine id when assigned randomly within a
+// continent of 64 indices, the birthday problem. Helps deciding whether
+// explicit coordination is needed, e.g. 10 machines collide with ~52%.
+func CollisionProbability(machines int) float64 {
+	slots := 1 << (bitsMachineID - 3)
+	if machines > slots {
+		return 1
+	}
+
+	// Probability of all distinct: 1 * (1 - 1/n) * ... * (1 - (k-1)/n).
+	distinct := 1.0
+	for i := 1; i < machines; i++ {
+		distinct *= 1 - float64(i)/float64(slots)
+	}
+
+	return 1 - distinct
+}
+
+// Returns the fraction of the theoretical maximum IDs of a single
+// machine that were minted within a window, i.e. how close to saturation
+// the machine is. Returns 0 for empty input or a non-positive window.
+func Utilization(ids []ID, window time.Duration) float64 {
+	if len(ids) == 0 || window <= 0 {
+		return 0
+	}
+
+	return float64(len(ids)) / (float64(MaxIDsPerSecond()) * window.Seconds())
+}
+
+// Groups IDs by machine id, preserving their order within each group.
+// Counts first, so that every group is allocated exactly once.
+func GroupByMachine(ids []ID) map[int64][]ID {
+	var counts [1 << bitsMachineID]int
+	groups := 0
+
+	for _, id := range ids {
+		if counts[id.MachineId()] == 0 {
+			groups++
+		}
+
+		counts[id.MachineId()]++
+	}
+
+	result := make(map[int64][]ID, groups)
+	for _, id := range ids {
+		m := id.MachineId()
+		if result[m] == nil {
+			result[m] = make([]ID, 0, counts[m])
+		}
+
+		result[m] = append(result[m], id)
+	}
+
+	return result
+}
+
+// Returns the gaps between the timestamps of consecutive IDs, e.g. to
+// surface burstiness and idle periods in a dump. IDs of the same
+// millisecond have a gap of 0. Unsorted input is sorted, the input slice
+// is left untouched. Returns nil for fewer than 2 IDs.
+func InterArrival(ids []ID) []time.Duration {
+	if len(ids) < 2 {
+		return nil
+	}
+
+	if !slices.IsSorted(ids) {
+		ids = slices.Clone(ids)
+		slices.Sort(ids)
+	}
+
+	gaps := make([]time.Duration, len(ids)-1)
+	for i := 1; i < len(ids); i++ {
+		gaps[i-1] = time.Duration(ids[i].EpochMillis()-ids[i-1].EpochMillis()) * time.Millisecond
+	}
+
+	return gaps
+}
@@ -0,0 +1,133 @@
+package snowflake
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMaxIDsPerSecond(t *testing.T) {
+	if got := MaxIDsPerSecond(); got != 4096000 {
+		t.Errorf("got '%d', want '4096000'", got)
+	}
+}
+
+func TestIDsUntilOverflow(t *testing.T) {
+	// ~135 years left from 2024 at 4096 IDs per millisecond.
+	lower := int64(130 * 365 * 24 * time.Hour / time.Millisecond * 4096)
+	upper := int64(1<<42) * 4096
+
+	if got := IDsUntilOverflow(); got < lower || got > upper {
+		t.Errorf("got '%d', want between '%d' and '%d'", got, lower, upper)
+	}
+}
+
+func TestCollisionProbability(t *testing.T) {
+	tests := []struct {
+		machines int
+		verify   float64
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 1.0 / 64},
+		{10, 0.5232},
+		{20, 0.9641},
+		{64, 1},
+		{65, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_CollisionProbability_%d", test.machines), func(t *testing.T) {
+			if got := CollisionProbability(test.machines); math.Abs(got-test.verify) > 1e-4 {
+				t.Errorf("got '%v', want '%v'", got, test.verify)
+			}
+		})
+	}
+}
+
+func TestUtilization(t *testing.T) {
+	// A quarter of the sequence space over 10 ms.
+	var ids []ID
+	for ms := int64(0); ms < 10; ms++ {
+		for seq := int64(0); seq < 1024; seq++ {
+			ids = append(ids, IDFromEpochMillis(ms, 35, seq))
+		}
+	}
+
+	tests := []struct {
+		ids    []ID
+		window time.Duration
+		verify float64
+	}{
+		{ids, 10 * time.Millisecond, 0.25},
+		{ids, 20 * time.Millisecond, 0.125},
+		{nil, time.Second, 0},
+		{ids, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Utilization_%d_%v", len(test.ids), test.window), func(t *testing.T) {
+			if got := Utilization(test.ids, test.window); math.Abs(got-test.verify) > 1e-9 {
+				t.Errorf("got '%f', want '%f'", got, test.verify)
+			}
+		})
+	}
+}
+
+func TestGroupByMachine(t *testing.T) {
+	a1, a2 := IDFromEpochMillis(1, 35, 0), IDFromEpochMillis(2, 35, 1)
+	b1 := IDFromEpochMillis(1, 0, 0)
+	c1, c2 := IDFromEpochMillis(3, 511, 7), IDFromEpochMillis(1, 511, 0)
+
+	groups := GroupByMachine([]ID{a1, b1, c1, a2, c2})
+	verify := map[int64][]ID{35: {a1, a2}, 0: {b1}, 511: {c1, c2}}
+
+	if !reflect.DeepEqual(groups, verify) {
+		t.Errorf("got '%v', want '%v'", groups, verify)
+	}
+
+	for m, group := range groups {
+		if cap(group) != len(group) {
+			t.Errorf("machine %d: got cap '%d', want '%d'", m, cap(group), len(group))
+		}
+	}
+
+	if got := GroupByMachine(nil); len(got) != 0 {
+		t.Errorf("got '%v', want empty", got)
+	}
+}
+
+func TestInterArrival(t *testing.T) {
+	ids := []ID{
+		IDFromEpochMillis(1000, 35, 0),
+		IDFromEpochMillis(1000, 35, 1),
+		IDFromEpochMillis(1005, 35, 0),
+		IDFromEpochMillis(1005, 36, 0),
+		IDFromEpochMillis(2005, 35, 0),
+	}
+
+	verify := []time.Duration{0, 5 * time.Millisecond, 0, time.Second}
+	reversed := slices.Clone(ids)
+	slices.Reverse(reversed)
+
+	tests := []struct {
+		ids    []ID
+		verify []time.Duration
+	}{
+		{ids, verify},
+		{reversed, verify},
+		{ids[:1], nil},
+		{nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_InterArrival_%d", len(test.ids)), func(t *testing.T) {
+			if got := InterArrival(test.ids); !slices.Equal(got, test.verify) {
+				t.Errorf("got '%v', want '%v'", got, test.verify)
+			}
+		})
+	}
+}
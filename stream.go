@@ -0,0 +1,73 @@
+package snowflake
+
+import "time"
+
+// OrderChecker detects producers violating the roughly sorted delivery
+// of a stream of IDs. The zero value tolerates no reordering at all.
+// Not safe for concurrent use.
+type OrderChecker struct {
+	// Maximum tolerated age of an ID compared to the newest one seen.
+	Tolerance time.Duration
+
+	latest int64
+}
+
+// Remembers the newest timestamp seen, and returns `ErrorOutOfOrder` if
+// the ID is older than that by more than the configured tolerance.
+func (c *OrderChecker) Check(id ID) error {
+	ts := id.Time()
+
+	if ts > c.latest {
+		c.latest = ts
+	} else if c.latest-ts > c.Tolerance.Milliseconds() {
+		return &ErrorOutOfOrder
+	}
+
+	return nil
+}
+
+// Merges ordered streams of IDs into a single ordered stream, e.g. to
+// reconcile the outputs of two generators during a failover. Waits for
+// a value of every open stream before emitting the smallest one, hence
+// a stalled stream stalls the output. The output is closed once all
+// streams are closed. ATTENTION: The output must be drained, otherwise
+// the merging goroutine leaks.
+func Merge(streams ...<-chan ID) <-chan ID {
+	out := make(chan ID)
+
+	go func() {
+		defer close(out)
+
+		// Current head of each stream, nil once a stream is closed.
+		heads := make([]*ID, len(streams))
+		next := func(i int) {
+			if id, ok := <-streams[i]; ok {
+				heads[i] = &id
+			} else {
+				heads[i] = nil
+			}
+		}
+
+		for i := range streams {
+			next(i)
+		}
+
+		for {
+			min := -1
+			for i, head := range heads {
+				if head != nil && (min < 0 || *head < *heads[min]) {
+					min = i
+				}
+			}
+
+			if min < 0 {
+				return
+			}
+
+			out <- *heads[min]
+			next(min)
+		}
+	}()
+
+	return out
+}
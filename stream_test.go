@@ -0,0 +1,75 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrderChecker(t *testing.T) {
+	checker := OrderChecker{Tolerance: 100 * time.Millisecond}
+
+	tests := []struct {
+		ms  int64
+		err error
+	}{
+		{1000, nil},
+		{1000, nil},
+		{1200, nil},
+		{1150, nil}, // within tolerance
+		{1100, nil}, // at tolerance
+		{1300, nil},
+		{1050, &ErrorOutOfOrder},
+		{1301, nil},
+	}
+
+	for i, test := range tests {
+		if err := checker.Check(IDFromEpochMillis(test.ms, 35, int64(i))); !errors.Is(err, test.err) {
+			t.Errorf("check %d at %d ms: got '%v', want '%v'", i, test.ms, err, test.err)
+		}
+	}
+
+	var strict OrderChecker
+	if err := strict.Check(IDFromEpochMillis(10, 35, 0)); err != nil {
+		t.Errorf("got '%v', want nil", err)
+	} else if err := strict.Check(IDFromEpochMillis(9, 35, 0)); !errors.Is(err, &ErrorOutOfOrder) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorOutOfOrder)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	feed := func(ids ...ID) <-chan ID {
+		ch := make(chan ID)
+		go func() {
+			defer close(ch)
+			for _, id := range ids {
+				ch <- id
+			}
+		}()
+
+		return ch
+	}
+
+	// Generator of the primary region, and its failover twin.
+	a := []ID{IDFromEpochMillis(1, 35, 0), IDFromEpochMillis(1, 35, 1), IDFromEpochMillis(4, 35, 0), IDFromEpochMillis(9, 35, 0)}
+	b := []ID{IDFromEpochMillis(2, 36, 0), IDFromEpochMillis(3, 36, 0), IDFromEpochMillis(4, 36, 0)}
+
+	var merged []ID
+	for id := range Merge(feed(a...), feed(b...), feed()) {
+		merged = append(merged, id)
+	}
+
+	if len(merged) != len(a)+len(b) {
+		t.Fatalf("got '%d' IDs, want '%d'", len(merged), len(a)+len(b))
+	}
+
+	for i := 1; i < len(merged); i++ {
+		if merged[i] <= merged[i-1] {
+			t.Errorf("got '%v' after '%v', want increasing", int64(merged[i]), int64(merged[i-1]))
+		}
+	}
+
+	if _, ok := <-Merge(); ok {
+		t.Errorf("got open channel, want closed")
+	}
+}
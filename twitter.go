@@ -0,0 +1,68 @@
+package snowflake
+
+import (
+	"fmt"
+	"time"
+)
+
+// Thu Nov 04 2010 01:42:54.657 UTC, the epoch of the original Twitter snowflake.
+const TwitterEpoch int64 = 1288834974657
+
+// Classic Twitter layout: 1 bit unused, 41 bit timestamp,
+// 10 bit machine ID and 12 bit sequence.
+const twitterBitsMachineID int64 = 10
+const twitterBitsSequence int64 = 12
+
+// Decodes a snowflake using the classic Twitter 41/10/12 layout relative
+// to the given epoch, e.g. to interpret legacy IDs during a migration.
+// The 10 bit machine ID is returned as is, since it has no continent split.
+func ParseTwitter(v int64, epoch time.Time) (ts time.Time, machine, seq int64) {
+	ts = time.UnixMilli(epoch.UnixMilli() + (v >> (twitterBitsMachineID + twitterBitsSequence))).UTC()
+	machine = (v >> twitterBitsSequence) & (1<<twitterBitsMachineID - 1)
+	seq = v & (1<<twitterBitsSequence - 1)
+
+	return ts, machine, seq
+}
+
+// Converts a snowflake into the classic Twitter 41/10/12 layout relative to
+// the target epoch. The 9 bit machine id is stored as is in the low bits of
+// the 10 bit Twitter machine ID, whose high bit is therefore always 0.
+// Returns `ErrorInvalid` if the timestamp does not fit 41 bits from the
+// target epoch, e.g. predates it.
+func (id ID) ToTwitter(targetEpoch time.Time) (int64, error) {
+	if id < 0 {
+		return 0, &ErrorInvalid
+	}
+
+	ms := id.Time() - targetEpoch.UnixMilli()
+	if ms < 0 || ms >= 1<<(63-twitterBitsMachineID-twitterBitsSequence) {
+		return 0, fmt.Errorf("%w: timestamp out of range for epoch %v", &ErrorInvalid, targetEpoch)
+	}
+
+	return ms<<(twitterBitsMachineID+twitterBitsSequence) |
+		id.MachineId()<<twitterBitsSequence |
+		id.MachineSequence(), nil
+}
+
+// Converts a classic Twitter layout snowflake relative to the source epoch
+// into a snowflake. The remapping is lossy by nature: Twitter machine IDs
+// have 10 bits, ours 9, hence only machine IDs below 512 are representable
+// and folding the others would collide. Those yield `ErrorInvalidMachineId`.
+// The continent of the result is the high 3 bits of the machine ID.
+func FromTwitterID(v int64, srcEpoch time.Time) (ID, error) {
+	if v < 0 {
+		return Invalid, &ErrorInvalid
+	}
+
+	ts, machine, seq := ParseTwitter(v, srcEpoch)
+	if machine > bitMapMachineId {
+		return Invalid, fmt.Errorf("%w: twitter machine %d exceeds %d", &ErrorInvalidMachineId, machine, bitMapMachineId)
+	}
+
+	id := IDFromEpochMillis(ts.UnixMilli()-Epoch, machine, seq)
+	if id == Invalid {
+		return Invalid, fmt.Errorf("%w: timestamp %v out of range", &ErrorInvalid, ts)
+	}
+
+	return id, nil
+}
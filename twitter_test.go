@@ -0,0 +1,92 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseTwitter(t *testing.T) {
+	tests := []struct {
+		id      int64
+		epoch   time.Time
+		time    string
+		machine int64
+		seq     int64
+	}{
+		// Tweet ID with the Twitter epoch.
+		{1541815603606036480, time.UnixMilli(TwitterEpoch), "2022-06-28 16:07:40.105 +0000 UTC", 378, 0},
+		// Example from the Discord documentation, which uses the same layout.
+		{175928847299117063, time.UnixMilli(1420070400000), "2016-04-30 11:18:25.796 +0000 UTC", 32, 7},
+		{0, time.UnixMilli(TwitterEpoch), "2010-11-04 01:42:54.657 +0000 UTC", 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_ParseTwitter_%d", test.id), func(t *testing.T) {
+			ts, machine, seq := ParseTwitter(test.id, test.epoch)
+
+			if ts.String() != test.time {
+				t.Errorf("got time '%v', want '%v'", ts, test.time)
+			} else if machine != test.machine || seq != test.seq {
+				t.Errorf("got '%d/%d', want '%d/%d'", machine, seq, test.machine, test.seq)
+			}
+		})
+	}
+}
+
+func TestTwitterRoundTrip(t *testing.T) {
+	twitterEpoch := time.UnixMilli(TwitterEpoch)
+
+	tests := []ID{
+		ID(305023354946072576),
+		IDFromEpochMillis(0, 0, 0),
+		IDFromEpochMillis(1<<40, 511, 4095),
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Test_Twitter_%d", int64(test)), func(t *testing.T) {
+			v, err := test.ToTwitter(twitterEpoch)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ts, machine, seq := ParseTwitter(v, twitterEpoch)
+			if ts.UnixMilli() != test.Time() || machine != test.MachineId() || seq != test.MachineSequence() {
+				t.Errorf("got '%v/%d/%d', want '%v/%d/%d'", ts.UnixMilli(), machine, seq, test.Time(), test.MachineId(), test.MachineSequence())
+			}
+
+			if id, err := FromTwitterID(v, twitterEpoch); err != nil || id != test {
+				t.Errorf("got '%v' (%v), want '%v'", int64(id), err, int64(test))
+			}
+		})
+	}
+}
+
+func TestTwitterInvalid(t *testing.T) {
+	twitterEpoch := time.UnixMilli(TwitterEpoch)
+
+	// Predates the target epoch.
+	if _, err := ID(0).ToTwitter(time.UnixMilli(Epoch + 1)); !errors.Is(err, &ErrorInvalid) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalid)
+	}
+
+	// Beyond 41 bits from the target epoch.
+	if _, err := MaxID().ToTwitter(twitterEpoch); !errors.Is(err, &ErrorInvalid) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalid)
+	}
+
+	// Tweet ID from 2022 of machine 378 fits, machine 512 does not.
+	if _, err := FromTwitterID(1541815603606036480, twitterEpoch); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := FromTwitterID(512<<twitterBitsSequence, twitterEpoch); !errors.Is(err, &ErrorInvalidMachineId) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalidMachineId)
+	}
+
+	// Tweet from 2010 predates Epoch.
+	if _, err := FromTwitterID(1<<22, twitterEpoch); !errors.Is(err, &ErrorInvalid) {
+		t.Errorf("got '%v', want '%v'", err, &ErrorInvalid)
+	}
+}